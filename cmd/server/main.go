@@ -8,8 +8,11 @@ import (
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/raxaris/ipromise-backend/config"
+	"github.com/raxaris/ipromise-backend/internal/authz"
 	"github.com/raxaris/ipromise-backend/internal/handlers"
+	"github.com/raxaris/ipromise-backend/internal/mailer"
 	"github.com/raxaris/ipromise-backend/internal/middleware"
+	"github.com/raxaris/ipromise-backend/internal/services"
 
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -29,14 +32,27 @@ func main() {
 	config.LoadEnv()
 	config.ConnectDB()
 
+	if err := services.LoadSigningKeys(); err != nil {
+		log.Fatal("❌ Ошибка загрузки ключей JWT: ", err)
+	}
+	services.LoadPasswordPolicy()
+	if err := authz.Load(config.PermissionsFile); err != nil {
+		log.Fatal("❌ Ошибка загрузки политики доступа: ", err)
+	}
+	services.SetMailer(mailer.FromConfig())
+	services.LoadOIDCProviders()
+	services.StartLoginAttemptRetention()
+	services.StartAuditRetention()
+
 	r := gin.Default()
+	r.Use(middleware.RequestID())
 
 	// CORS Middleware
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", middleware.RequestIDHeader},
+		ExposeHeaders:    []string{"Content-Length", middleware.RequestIDHeader},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -45,16 +61,44 @@ func main() {
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// 🔹 Публичные маршруты
-	r.GET("/users/:username", handlers.GetPublicUserHandler) // Публичный профиль без email
-	r.GET("/promises", handlers.GetAllPublicPromisesHandler) // Все обещания (без личных данных)
-	r.GET("/promises/:id", handlers.GetPromiseByIDHandler)   // Одно обещание
+	r.GET("/users/:username", handlers.GetPublicUserHandler)                                                                   // Публичный профиль без email
+	r.GET("/promises", handlers.GetAllPublicPromisesHandler)                                                                   // Все обещания (без личных данных)
+	r.GET("/promises/search", middleware.OptionalAuthMiddleware(services.ScopePromisesRead), handlers.SearchPromisesHandler)   // Поиск
+	r.GET("/promises/:id", middleware.OptionalAuthMiddleware(services.ScopePromisesRead), handlers.GetPromiseByIDHandler)      // Одно обещание
+	r.GET("/promises/:id/tree", middleware.OptionalAuthMiddleware(services.ScopePromisesRead), handlers.GetPromiseTreeHandler) // Обещание с прогрессом
+
+	// Жалобы на публичный контент
+	r.POST("/promises/:id/report", middleware.AuthMiddleware(), handlers.ReportPromiseHandler)
+	r.POST("/users/:username/report", middleware.AuthMiddleware(), handlers.ReportUserHandler)
+
+	// Публичные ключи для проверки JWT другими сервисами
+	r.GET("/.well-known/jwks.json", handlers.JWKSHandler)
 
 	// 🔹 Маршруты для аутентификации
 	auth := r.Group("/auth")
 	{
 		auth.POST("/signup", handlers.SignupHandler)        // Регистрация
 		auth.POST("/login", handlers.LoginHandler)          // Логин
+		auth.POST("/login/2fa", handlers.LoginMFAHandler)   // Второй шаг входа с 2FA
 		auth.POST("/refresh", handlers.RefreshTokenHandler) // Обновление токена
+		auth.POST("/logout", handlers.LogoutHandler)        // Выход (текущая сессия)
+
+		// Восстановление доступа
+		auth.POST("/password/forgot", handlers.ForgotPasswordHandler) // Письмо со ссылкой сброса
+		auth.POST("/password/reset", handlers.ResetPasswordHandler)   // Новый пароль по токену
+
+		// Вход без пароля по ссылке из письма
+		auth.POST("/magic-link", handlers.RequestMagicLinkHandler)     // Письмо со ссылкой
+		auth.POST("/magic-link/login", handlers.MagicLinkLoginHandler) // Токены по ссылке
+
+		// Подтверждение email
+		auth.POST("/verify-email", handlers.VerifyEmailHandler)                                            // Подтверждение по токену
+		auth.POST("/verify-email/resend", middleware.AuthMiddleware(), handlers.ResendVerificationHandler) // Повторное письмо
+
+		// Вход через внешних OIDC-провайдеров
+		auth.GET("/oidc", handlers.GetOIDCProvidersHandler)                // Настроенные провайдеры
+		auth.GET("/oidc/:provider/login", handlers.OIDCLoginHandler)       // Редирект к провайдеру
+		auth.GET("/oidc/:provider/callback", handlers.OIDCCallbackHandler) // Возврат от провайдера
 	}
 
 	// 🔹 Авторизованные пользователи
@@ -64,28 +108,90 @@ func main() {
 		user.GET("/", handlers.GetCurrentUserHandler) // Личный профиль
 		user.PUT("/", handlers.UpdateUserHandler)     // Обновление своего профиля
 
-		// Обещания авторизованного пользователя
-		user.GET("/promises", handlers.GetUserPromisesHandler)      // Получить свои обещания
-		user.POST("/promises", handlers.CreatePromiseHandler)       // Создать обещание
-		user.PUT("/promises/:id", handlers.UpdatePromiseHandler)    // Обновить обещание
-		user.DELETE("/promises/:id", handlers.DeletePromiseHandler) // Удалить обещание
+		user.DELETE("/impersonation", handlers.EndOwnImpersonationHandler) // Завершить вход администратора под пользователем
+
+		// Безопасность аккаунта – недоступна администратору, вошедшему под пользователем
+		security := user.Group("", middleware.DenyImpersonation())
+
+		// Смена учётных данных
+		security.POST("/password", handlers.ChangePasswordHandler) // Смена пароля
+		security.POST("/email", handlers.ChangeEmailHandler)       // Смена email с подтверждением
+
+		// Сессии пользователя
+		security.GET("/sessions", handlers.GetSessionsHandler)          // Активные сессии
+		security.DELETE("/sessions", handlers.RevokeAllSessionsHandler) // Выход со всех устройств
+		security.DELETE("/sessions/:id", handlers.RevokeSessionHandler) // Завершить одну сессию
+
+		// Двухфакторная аутентификация
+		security.POST("/2fa/setup", handlers.SetupTOTPHandler)                        // Секрет и QR-код
+		security.POST("/2fa/confirm", handlers.ConfirmTOTPHandler)                    // Включение по первому коду
+		security.DELETE("/2fa", handlers.DisableTOTPHandler)                          // Отключение
+		security.POST("/2fa/recovery-codes", handlers.RegenerateRecoveryCodesHandler) // Новые коды восстановления
+
+		// Персональные токены для скриптов и интеграций
+		security.GET("/tokens", handlers.GetPersonalTokensHandler)          // Список токенов
+		security.POST("/tokens", handlers.CreatePersonalTokenHandler)       // Создать токен
+		security.DELETE("/tokens/:id", handlers.RevokePersonalTokenHandler) // Отозвать токен
+	}
+
+	// 🔹 Обещания авторизованного пользователя (доступны и по персональным токенам со scope)
+	promisesRead := middleware.AuthMiddleware(services.ScopePromisesRead)
+	promisesWrite := middleware.AuthMiddleware(services.ScopePromisesWrite)
+	userPromises := r.Group("/profile/promises")
+	{
+		userPromises.GET("", promisesRead, handlers.GetUserPromisesHandler)       // Получить свои обещания
+		userPromises.POST("", promisesWrite, handlers.CreatePromiseHandler)       // Создать обещание
+		userPromises.PUT("/:id", promisesWrite, handlers.UpdatePromiseHandler)    // Обновить обещание
+		userPromises.DELETE("/:id", promisesWrite, handlers.DeletePromiseHandler) // Удалить обещание
+	}
+
+	// 🔹 Модерация публичного контента (без доступа к данным пользователей)
+	can := middleware.RequirePermission
+	moderation := r.Group("/moderation")
+	moderation.Use(middleware.AuthMiddleware())
+	{
+		moderation.GET("/promises", can(authz.ModerationRead), handlers.GetRecentPublicPromisesHandler)          // Свежие публичные обещания
+		moderation.POST("/promises/:id/hide", can(authz.PromiseHide), handlers.HidePromiseHandler)               // Скрыть
+		moderation.POST("/promises/:id/unhide", can(authz.PromiseHide), handlers.UnhidePromiseHandler)           // Вернуть
+		moderation.PUT("/promises/:id/title", can(authz.PromiseEditTitle), handlers.ModeratePromiseTitleHandler) // Исправить заголовок
+
+		// Жалобы
+		moderation.GET("/reports", can(authz.ReportReview), handlers.GetReportQueueHandler)               // Очередь
+		moderation.GET("/reports/target", can(authz.ReportReview), handlers.GetTargetReportsHandler)      // Жалобы на объект
+		moderation.POST("/reports/resolve", can(authz.ReportReview), handlers.ResolveReportsHandler)      // Решение
+		moderation.GET("/reports/decisions", can(authz.ReportReview), handlers.GetReportDecisionsHandler) // История решений
 	}
 
-	// 🔹 Админские маршруты (полный доступ)
+	// 🔹 Административные маршруты (доступ по разрешениям роли, см. PERMISSIONS_FILE)
 	admin := r.Group("/admin")
-	admin.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+	admin.Use(middleware.AuthMiddleware())
 	{
-		// Полный доступ к пользователям
-		admin.GET("/users", handlers.GetAllUsersHandler)
-		admin.GET("/users/:id", handlers.GetUserByIDHandler)
-		admin.GET("/users/u/:username", handlers.GetUserByUsernameHandler)
-		admin.PUT("/users/:id", handlers.UpdateUserHandler)
-		admin.DELETE("/users/:id", handlers.DeleteUserHandler)
-
-		// Полный доступ к обещаниям
-		admin.GET("/promises", handlers.GetAllPromisesHandler)
-		admin.PUT("/promises/:id", handlers.UpdatePromiseHandler)
-		admin.DELETE("/promises/:id", handlers.DeletePromiseHandler)
+		// Пользователи
+		admin.GET("/users", can(authz.UserRead), handlers.GetAllUsersHandler)
+		admin.GET("/users/:id", can(authz.UserRead), handlers.GetUserByIDHandler)
+		admin.GET("/users/u/:username", can(authz.UserRead), handlers.GetUserByUsernameHandler)
+		admin.PUT("/users/:id", can(authz.UserUpdate), handlers.UpdateUserHandler)
+		admin.DELETE("/users/:id", can(authz.UserDelete), handlers.DeleteUserHandler)
+		admin.POST("/users/:id/unlock", can(authz.UserUnlock), handlers.UnlockUserHandler)
+		admin.POST("/users/:id/suspend", can(authz.UserSuspend), handlers.SuspendUserHandler)
+		admin.POST("/users/:id/ban", can(authz.UserBan), handlers.BanUserHandler)
+		admin.DELETE("/users/:id/restriction", can(authz.UserSuspend), handlers.LiftRestrictionHandler)
+
+		// Вход под пользователем (разбор проблем с приватными данными) и его журнал
+		admin.POST("/users/:id/impersonate", can(authz.UserImpersonate), handlers.ImpersonateUserHandler)
+		admin.DELETE("/impersonations/:token_id", can(authz.UserImpersonate), handlers.EndImpersonationHandler)
+		admin.GET("/impersonation-log", can(authz.ImpersonationLogRead), handlers.GetImpersonationLogsHandler)
+
+		// Попытки входа (разбор атак перебором)
+		admin.GET("/login-attempts", can(authz.LoginAttemptsRead), handlers.GetLoginAttemptsHandler)
+
+		// Журнал аудита привилегированных и значимых для безопасности действий
+		admin.GET("/audit", can(authz.AuditRead), handlers.GetAuditLogsHandler)
+
+		// Обещания
+		admin.GET("/promises", can(authz.PromiseRead), handlers.GetAllPromisesHandler)
+		admin.PUT("/promises/:id", can(authz.PromiseUpdate), handlers.UpdatePromiseHandler)
+		admin.DELETE("/promises/:id", can(authz.PromiseDelete), handlers.DeletePromiseHandler)
 	}
 
 	port := "8080"
@@ -3,11 +3,94 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
-var JWTSecret string
+var (
+	JWTSecret           string
+	JWTAlgorithm        string   // HS256, RS256 или EdDSA
+	JWTPrivateKeyFile   string   // PEM-файл активного ключа подписи (RS256/EdDSA)
+	JWTKeyID            string   // kid активного ключа; по умолчанию – отпечаток ключа
+	JWTPublicKeyFiles   []string // Ключи, которыми ещё проверяются токены: "kid=path" или "path"
+	JWTIssuer           string   // Значение `iss` в Access-токенах
+	JWTAudience         string   // Значение `aud` в Access-токенах
+	RefreshTokenHashKey string
+
+	PasswordHashAlgorithm string // argon2id или bcrypt; старые хеши пересчитываются при входе
+	PasswordArgon2Memory  uint32 // КиБ
+	PasswordArgon2Time    uint32
+	PasswordArgon2Threads uint8
+	PasswordBcryptCost    int
+
+	// Политика паролей
+	PasswordMinLength        int
+	PasswordMaxLength        int
+	PasswordRequireLower     bool
+	PasswordRequireUpper     bool
+	PasswordRequireDigit     bool
+	PasswordRequireSymbol    bool
+	PasswordRejectPersonal   bool   // Запрет паролей с именем пользователя или email
+	PasswordBreachedFile     string // Файл Pwned Passwords (SHA1:COUNT, отсортирован); пусто – проверка выключена
+	PasswordBreachedMinCount int    // Сколько раз пароль должен встретиться в утечках, чтобы его отклонить
+
+	AppBaseURL   string // Адрес, от которого строятся ссылки в письмах
+	MailDriver   string // smtp или log
+	MailFrom     string
+	MailLogFile  string // Файл для писем при MAIL_DRIVER=log; пусто – вывод в лог
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+
+	PasswordResetWindow      time.Duration // Окно для лимитов на запрос ссылок сброса пароля
+	PasswordResetMaxPerEmail int
+	PasswordResetMaxPerIP    int
+
+	// Запрет публичных обещаний до подтверждения email
+	RequireVerifiedEmailForPublic bool
+
+	EmailVerificationWindow     time.Duration // Окно для лимита повторных писем подтверждения email
+	EmailVerificationMaxPerUser int
+
+	MFAIssuer        string   // Название сервиса в приложении-аутентификаторе
+	MFARequiredRoles []string // Роли, которым закрыт привилегированный доступ без входа через 2FA
+
+	LoginMaxFailures      int           // Неудачных входов подряд до блокировки аккаунта
+	LoginLockoutBase      time.Duration // Первая блокировка; каждая следующая вдвое дольше
+	LoginLockoutMax       time.Duration
+	LoginIPMaxFailures    int // Неудачных входов с одного IP за окно LoginIPWindow
+	LoginIPWindow         time.Duration
+	LoginAttemptRetention time.Duration // Сколько хранить журнал попыток входа; 0 – бессрочно
+
+	OIDCProviders []OIDCProviderConfig
+
+	PermissionsFile string // YAML с ролями и разрешениями; пусто – встроенная политика
+
+	ReportSuspensionDuration time.Duration // На сколько приостанавливается аккаунт по решению по жалобе
+
+	ImpersonationTTL time.Duration // Сколько действует токен входа администратора под пользователем
+
+	AuditRetention time.Duration // Сколько хранить записи журнала аудита; 0 – бессрочно
+
+	MagicLinkTTL         time.Duration // Сколько действует ссылка входа без пароля
+	MagicLinkWindow      time.Duration // Окно для лимитов на запрос ссылок
+	MagicLinkMaxPerEmail int
+	MagicLinkMaxPerIP    int
+)
+
+// OIDCProviderConfig – настройки OIDC-провайдера для входа через внешний аккаунт
+type OIDCProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
 
 func LoadEnv() {
 	err := godotenv.Load()
@@ -15,8 +98,173 @@ func LoadEnv() {
 		log.Fatal("❌ Ошибка загрузки .env файла")
 	}
 
+	JWTAlgorithm = getEnvDefault("JWT_ALGORITHM", "HS256")
+	JWTPrivateKeyFile = os.Getenv("JWT_PRIVATE_KEY_FILE")
+	JWTKeyID = os.Getenv("JWT_KEY_ID")
+	JWTPublicKeyFiles = splitList(os.Getenv("JWT_PUBLIC_KEY_FILES"))
+	JWTIssuer = getEnvDefault("JWT_ISSUER", "ipromise")
+	JWTAudience = getEnvDefault("JWT_AUDIENCE", "ipromise-api")
+
 	JWTSecret = os.Getenv("JWT_SECRET")
-	if JWTSecret == "" {
+	if JWTAlgorithm == "HS256" && JWTSecret == "" {
 		log.Fatal("❌ Переменная окружения JWT_SECRET не установлена! Приложение не может работать без нее.")
 	}
+	if JWTAlgorithm != "HS256" && JWTPrivateKeyFile == "" {
+		log.Fatal("❌ Для алгоритма " + JWTAlgorithm + " нужна переменная окружения JWT_PRIVATE_KEY_FILE")
+	}
+
+	// Ключ для хеширования Refresh-токенов в БД
+	RefreshTokenHashKey = os.Getenv("REFRESH_TOKEN_HASH_KEY")
+	if RefreshTokenHashKey == "" {
+		if JWTSecret == "" {
+			log.Fatal("❌ Переменная окружения REFRESH_TOKEN_HASH_KEY не установлена")
+		}
+		log.Println("⚠️ REFRESH_TOKEN_HASH_KEY не задан, используется JWT_SECRET")
+		RefreshTokenHashKey = JWTSecret
+	}
+
+	// Хеширование паролей
+	PasswordHashAlgorithm = getEnvDefault("PASSWORD_HASH_ALGORITHM", "argon2id")
+	PasswordArgon2Memory = uint32(getEnvInt("PASSWORD_ARGON2_MEMORY", 64*1024))
+	PasswordArgon2Time = uint32(getEnvInt("PASSWORD_ARGON2_TIME", 3))
+	PasswordArgon2Threads = uint8(getEnvInt("PASSWORD_ARGON2_THREADS", 2))
+	PasswordBcryptCost = getEnvInt("PASSWORD_BCRYPT_COST", 10)
+	if PasswordHashAlgorithm != "argon2id" && PasswordHashAlgorithm != "bcrypt" {
+		log.Fatal("❌ PASSWORD_HASH_ALGORITHM должен быть argon2id или bcrypt")
+	}
+	if PasswordArgon2Memory < 8*uint32(PasswordArgon2Threads) || PasswordArgon2Time < 1 || PasswordArgon2Threads < 1 {
+		log.Fatal("❌ Некорректные параметры PASSWORD_ARGON2_*")
+	}
+	if PasswordBcryptCost < 4 || PasswordBcryptCost > 31 {
+		log.Fatal("❌ PASSWORD_BCRYPT_COST должен быть от 4 до 31")
+	}
+
+	PasswordMinLength = getEnvInt("PASSWORD_MIN_LENGTH", 8)
+	PasswordMaxLength = getEnvInt("PASSWORD_MAX_LENGTH", 128)
+	PasswordRequireLower = getEnvBool("PASSWORD_REQUIRE_LOWER", false)
+	PasswordRequireUpper = getEnvBool("PASSWORD_REQUIRE_UPPER", false)
+	PasswordRequireDigit = getEnvBool("PASSWORD_REQUIRE_DIGIT", false)
+	PasswordRequireSymbol = getEnvBool("PASSWORD_REQUIRE_SYMBOL", false)
+	PasswordRejectPersonal = getEnvBool("PASSWORD_REJECT_PERSONAL", true)
+	PasswordBreachedFile = os.Getenv("PASSWORD_BREACHED_FILE")
+	PasswordBreachedMinCount = getEnvInt("PASSWORD_BREACHED_MIN_COUNT", 1)
+	if PasswordBreachedFile != "" {
+		if _, err := os.Stat(PasswordBreachedFile); err != nil {
+			log.Fatal("❌ Файл PASSWORD_BREACHED_FILE недоступен: ", err)
+		}
+	}
+
+	// Почта
+	AppBaseURL = strings.TrimRight(getEnvDefault("APP_BASE_URL", "http://localhost:8080"), "/")
+	MailDriver = getEnvDefault("MAIL_DRIVER", "log")
+	MailFrom = getEnvDefault("MAIL_FROM", "iPromise <no-reply@ipromise.local>")
+	MailLogFile = os.Getenv("MAIL_LOG_FILE")
+	SMTPHost = os.Getenv("SMTP_HOST")
+	SMTPPort = getEnvDefault("SMTP_PORT", "587")
+	SMTPUsername = os.Getenv("SMTP_USERNAME")
+	SMTPPassword = os.Getenv("SMTP_PASSWORD")
+	if MailDriver == "smtp" && SMTPHost == "" {
+		log.Fatal("❌ Для MAIL_DRIVER=smtp нужна переменная окружения SMTP_HOST")
+	}
+
+	// Сброс пароля
+	PasswordResetWindow = getEnvDuration("PASSWORD_RESET_WINDOW", time.Hour)
+	PasswordResetMaxPerEmail = getEnvInt("PASSWORD_RESET_MAX_PER_EMAIL", 3)
+	PasswordResetMaxPerIP = getEnvInt("PASSWORD_RESET_MAX_PER_IP", 10)
+
+	RequireVerifiedEmailForPublic = getEnvBool("REQUIRE_VERIFIED_EMAIL_FOR_PUBLIC", false)
+
+	// Повторная отправка письма подтверждения email
+	EmailVerificationWindow = getEnvDuration("EMAIL_VERIFICATION_WINDOW", time.Hour)
+	EmailVerificationMaxPerUser = getEnvInt("EMAIL_VERIFICATION_MAX_PER_USER", 3)
+
+	// Двухфакторная аутентификация
+	MFAIssuer = getEnvDefault("MFA_ISSUER", "iPromise")
+	MFARequiredRoles = splitList(os.Getenv("MFA_REQUIRED_ROLES"))
+
+	// Защита от перебора паролей
+	LoginMaxFailures = getEnvInt("LOGIN_MAX_FAILURES", 5)
+	LoginLockoutBase = getEnvDuration("LOGIN_LOCKOUT_BASE", time.Minute)
+	LoginLockoutMax = getEnvDuration("LOGIN_LOCKOUT_MAX", time.Hour)
+	LoginIPMaxFailures = getEnvInt("LOGIN_IP_MAX_FAILURES", 20)
+	LoginIPWindow = getEnvDuration("LOGIN_IP_WINDOW", 15*time.Minute)
+	LoginAttemptRetention = getEnvDuration("LOGIN_ATTEMPT_RETENTION", 90*24*time.Hour)
+
+	PermissionsFile = os.Getenv("PERMISSIONS_FILE")
+	ReportSuspensionDuration = getEnvDuration("REPORT_SUSPENSION_DURATION", 7*24*time.Hour)
+	ImpersonationTTL = getEnvDuration("IMPERSONATION_TTL", 15*time.Minute)
+	AuditRetention = getEnvDuration("AUDIT_RETENTION", 365*24*time.Hour)
+
+	// Вход по ссылке из письма
+	MagicLinkTTL = getEnvDuration("MAGIC_LINK_TTL", 15*time.Minute)
+	MagicLinkWindow = getEnvDuration("MAGIC_LINK_WINDOW", time.Hour)
+	MagicLinkMaxPerEmail = getEnvInt("MAGIC_LINK_MAX_PER_EMAIL", 3)
+	MagicLinkMaxPerIP = getEnvInt("MAGIC_LINK_MAX_PER_IP", 10)
+
+	// Вход через OIDC: OIDC_PROVIDERS=google,mock и OIDC_<ИМЯ>_ISSUER, _CLIENT_ID, _CLIENT_SECRET, _REDIRECT_URL, _SCOPES
+	OIDCProviders = nil
+	for _, name := range splitList(os.Getenv("OIDC_PROVIDERS")) {
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		provider := OIDCProviderConfig{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  getEnvDefault(prefix+"REDIRECT_URL", AppBaseURL+"/auth/oidc/"+name+"/callback"),
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+		}
+		if provider.Issuer == "" || provider.ClientID == "" {
+			log.Fatal("❌ Для OIDC-провайдера " + name + " нужны " + prefix + "ISSUER и " + prefix + "CLIENT_ID")
+		}
+		OIDCProviders = append(OIDCProviders, provider)
+	}
+}
+
+// splitList – разбирает список значений через запятую, пропуская пустые
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getEnvDefault – значение переменной окружения или fallback, если она не задана
+func getEnvDefault(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// getEnvInt – целое значение переменной окружения или fallback
+func getEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// getEnvDuration – длительность из переменной окружения (например, 15m) или fallback
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// getEnvBool – булево значение переменной окружения (true/1/yes) или fallback
+func getEnvBool(key string, fallback bool) bool {
+	switch strings.ToLower(os.Getenv(key)) {
+	case "true", "1", "yes":
+		return true
+	case "false", "0", "no":
+		return false
+	default:
+		return fallback
+	}
 }
@@ -2,6 +2,8 @@ module github.com/raxaris/ipromise-backend
 
 go 1.23.4
 
+require github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
+
 require (
 	github.com/KyleBanks/depth v1.2.1 // indirect
 	github.com/PuerkitoBio/purell v1.2.1 // indirect
@@ -0,0 +1,138 @@
+package authz
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// Разрешения. Для действий над объектами пользователей проверяются варианты .any и .own.
+const (
+	PromiseRead   = "promise.read"
+	PromiseUpdate = "promise.update"
+	PromiseDelete = "promise.delete"
+
+	// Модерация публичного контента
+	PromiseHide      = "promise.hide"
+	PromiseEditTitle = "promise.title.edit"
+	ModerationRead   = "moderation.read"
+
+	UserRead    = "user.read"
+	UserUpdate  = "user.update"
+	UserDelete  = "user.delete"
+	UserSetRole = "user.role.set"
+	UserUnlock  = "user.unlock"
+	UserBan     = "user.ban"
+	UserSuspend = "user.suspend"
+
+	// Вход администратора под пользователем и журнал таких входов
+	UserImpersonate      = "user.impersonate"
+	ImpersonationLogRead = "impersonation_log.read"
+
+	ReportReview = "report.review"
+
+	LoginAttemptsRead = "login_attempts.read"
+	AuditRead         = "audit.read"
+)
+
+const (
+	scopeAny = ".any"
+	scopeOwn = ".own"
+)
+
+//go:embed permissions.yaml
+var defaultPolicy []byte
+
+// Actor – тот, кто выполняет действие
+type Actor struct {
+	ID   uuid.UUID
+	Role string
+}
+
+// Resource – объект, у которого есть владелец (обещание, профиль)
+type Resource interface {
+	OwnerID() uuid.UUID
+}
+
+// Policy – разрешения ролей
+type Policy struct {
+	roles map[string][]string
+}
+
+type policyFile struct {
+	Roles map[string][]string `yaml:"roles"`
+}
+
+var current = mustParse(defaultPolicy)
+
+// Load – загружает политику из файла; пустой путь – встроенная политика по умолчанию
+func Load(path string) error {
+	if path == "" {
+		current = mustParse(defaultPolicy)
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	policy, err := Parse(data)
+	if err != nil {
+		return err
+	}
+	current = policy
+	return nil
+}
+
+// Parse – разбирает политику в формате YAML
+func Parse(data []byte) (*Policy, error) {
+	var file policyFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if len(file.Roles) == 0 {
+		return nil, fmt.Errorf("в политике нет ни одной роли")
+	}
+	return &Policy{roles: file.Roles}, nil
+}
+
+func mustParse(data []byte) *Policy {
+	policy, err := Parse(data)
+	if err != nil {
+		panic("❌ Ошибка встроенной политики доступа: " + err.Error())
+	}
+	return policy
+}
+
+// IsKnownRole – описана ли роль в политике
+func IsKnownRole(role string) bool {
+	_, ok := current.roles[role]
+	return ok
+}
+
+// HasPermission – есть ли у роли разрешение (с учётом шаблонов)
+func HasPermission(role, permission string) bool {
+	for _, granted := range current.roles[role] {
+		if granted == "*" || granted == permission {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(granted, "*"); ok && strings.HasPrefix(permission, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Can – может ли actor выполнить action над resource.
+// Подходит само разрешение action, action.any, а для своего объекта – action.own.
+// resource может быть nil, тогда проверяется действие над любым объектом.
+func Can(actor Actor, action string, resource Resource) bool {
+	if HasPermission(actor.Role, action) || HasPermission(actor.Role, action+scopeAny) {
+		return true
+	}
+	return resource != nil && resource.OwnerID() == actor.ID && HasPermission(actor.Role, action+scopeOwn)
+}
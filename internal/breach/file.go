@@ -0,0 +1,116 @@
+package breach
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PrefixLength – длина префикса SHA-1, по которому ищется диапазон (как в API Pwned Passwords)
+const PrefixLength = 5
+
+// Checker – проверка пароля по базе утечек
+type Checker interface {
+	// Count – сколько раз пароль встречался в утечках (0 – не найден)
+	Count(password string) (int, error)
+}
+
+// FileChecker – локальный файл в формате Pwned Passwords: строки "SHA1:COUNT",
+// хеши в верхнем регистре, файл отсортирован по хешу.
+// Поиск k-анонимный: по префиксу хеша выбирается диапазон строк, в нём сравниваются суффиксы,
+// поэтому при замене файла на удалённый API пароль и полный хеш наружу не уходят.
+type FileChecker struct {
+	Path string
+}
+
+// Count – ищет пароль в файле
+func (f *FileChecker) Count(password string) (int, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:PrefixLength], hash[PrefixLength:]
+
+	suffixes, err := f.Range(prefix)
+	if err != nil {
+		return 0, err
+	}
+	return suffixes[suffix], nil
+}
+
+// Range – все суффиксы хешей с данным префиксом и число их появлений
+func (f *FileChecker) Range(prefix string) (map[string]int, error) {
+	file, err := os.Open(f.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	// Двоичный поиск первой строки, не меньшей префикса
+	var searchErr error
+	offset := sort.Search(int(info.Size()), func(off int) bool {
+		_, key, err := lineAt(file, int64(off))
+		if err != nil {
+			searchErr = err
+			return true
+		}
+		return key == "" || key >= prefix
+	})
+	if searchErr != nil {
+		return nil, searchErr
+	}
+
+	start, _, err := lineAt(file, int64(offset))
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]int{}
+	scanner := bufio.NewScanner(io.NewSectionReader(file, start, info.Size()-start))
+	for scanner.Scan() {
+		line := strings.ToUpper(strings.TrimSpace(scanner.Text()))
+		if !strings.HasPrefix(line, prefix) {
+			break
+		}
+		hashPart, countPart, _ := strings.Cut(line, ":")
+		count, err := strconv.Atoi(countPart)
+		if err != nil {
+			count = 1
+		}
+		result[hashPart[PrefixLength:]] = count
+	}
+	return result, scanner.Err()
+}
+
+// lineAt – начало и хеш первой полной строки, начинающейся не раньше off.
+// Пустой хеш означает конец файла.
+func lineAt(file *os.File, off int64) (int64, string, error) {
+	reader := bufio.NewReader(io.NewSectionReader(file, off, 1<<62))
+	start := off
+	if off > 0 {
+		// Пропускаем хвост строки, в середину которой попали
+		skipped, err := reader.ReadString('\n')
+		if err == io.EOF {
+			return off + int64(len(skipped)), "", nil
+		}
+		if err != nil {
+			return 0, "", err
+		}
+		start += int64(len(skipped))
+	}
+
+	line, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return 0, "", err
+	}
+	hashPart, _, _ := strings.Cut(strings.TrimSpace(line), ":")
+	return start, strings.ToUpper(hashPart), nil
+}
@@ -0,0 +1,20 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditLogFilter – фильтры журнала аудита; пустые поля не учитываются
+type AuditLogFilter struct {
+	ActorID    *uuid.UUID
+	Action     string // Точное действие или префикс с точкой на конце ("user.")
+	TargetType string
+	TargetID   *uuid.UUID
+	RequestID  string
+	IP         string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+}
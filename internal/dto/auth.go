@@ -1,10 +1,16 @@
 package dto
 
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
 // SignupRequest – DTO для регистрации
 type SignupRequest struct {
 	Username        string `json:"username" binding:"required"`
 	Email           string `json:"email" binding:"required,email"`
-	Password        string `json:"password" binding:"required,min=8"`
+	Password        string `json:"password" binding:"required"`
 	ConfirmPassword string `json:"confirm_password" binding:"required"`
 }
 
@@ -18,3 +24,39 @@ type LoginRequest struct {
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
 }
+
+// ForgotPasswordRequest – DTO запроса на сброс пароля
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest – DTO установки нового пароля по токену из письма
+type ResetPasswordRequest struct {
+	Token           string `json:"token" binding:"required"`
+	Password        string `json:"password" binding:"required"`
+	ConfirmPassword string `json:"confirm_password" binding:"required"`
+}
+
+// MagicLinkRequest – DTO запроса ссылки для входа без пароля
+type MagicLinkRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// MagicLinkLoginRequest – DTO входа по токену из ссылки
+type MagicLinkLoginRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// VerifyEmailRequest – DTO подтверждения email по токену из письма
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// SessionResponse – DTO активной сессии пользователя
+type SessionResponse struct {
+	ID         uuid.UUID `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+}
@@ -0,0 +1,24 @@
+package dto
+
+// TOTPSetupResponse – данные для добавления аккаунта в приложение-аутентификатор
+type TOTPSetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+	QRCodePNG  string `json:"qr_code_png"` // PNG в base64
+}
+
+// TOTPCodeRequest – DTO с кодом из приложения или кодом восстановления
+type TOTPCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// MFALoginRequest – DTO второго шага входа
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"` // Код TOTP или код восстановления
+}
+
+// RecoveryCodesResponse – одноразовые коды восстановления (показываются один раз)
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
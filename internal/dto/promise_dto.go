@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/internal/models"
 )
 
 type CreatePromiseRequest struct {
@@ -23,6 +24,16 @@ type UpdatePromiseRequest struct {
 	IsPrivate   *bool      `json:"is_private,omitempty"` // 🔹 Добавлено
 }
 
+// HidePromiseRequest – DTO скрытия обещания модератором
+type HidePromiseRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// ModeratePromiseTitleRequest – DTO правки заголовка модератором
+type ModeratePromiseTitleRequest struct {
+	Title string `json:"title" binding:"required,max=255"`
+}
+
 type PromiseResponse struct {
 	ID          uuid.UUID  `json:"id"`
 	UserID      uuid.UUID  `json:"user_id"`
@@ -33,3 +44,42 @@ type PromiseResponse struct {
 	Status      string     `json:"status"`
 	CreatedAt   time.Time  `json:"created_at"`
 }
+
+// PromiseFilter – фильтры списков обещаний; пустые поля не учитываются
+type PromiseFilter struct {
+	OwnerID      *uuid.UUID
+	Status       string
+	DeadlineFrom *time.Time
+	DeadlineTo   *time.Time
+	ParentID     *uuid.UUID
+	RootOnly     bool       // Только основные обещания, без обновлений прогресса
+	PublicOnly   bool       // Только публичные, не скрытые модерацией и не принадлежащие ограниченным авторам
+	ViewerID     *uuid.UUID // При PublicOnly – ещё и все обещания этого пользователя, включая приватные
+}
+
+// PromiseSearchHit – найденное обещание с релевантностью и фрагментами, где совпадения обёрнуты в <mark>.
+// Текст фрагментов экранирован для HTML.
+type PromiseSearchHit struct {
+	models.Promise
+	Rank                 float64 `json:"rank"`
+	TitleHighlight       string  `json:"title_highlight"`
+	DescriptionHighlight string  `json:"description_highlight"`
+}
+
+// PromiseTreeNode – обещание и его обновления прогресса в порядке создания
+type PromiseTreeNode struct {
+	models.Promise
+	Progress []PromiseTreeNode `json:"progress"`
+}
+
+// PromiseTree – основное обещание с деревом прогресса и сводкой по видимым записям.
+// LatestStatus – статус последней записи прогресса (без записей – статус обещания);
+// Percentage – 100, если обещание выполнено, иначе доля выполненных записей прогресса.
+type PromiseTree struct {
+	PromiseTreeNode
+	ProgressCount   int    `json:"progress_count"`
+	InProgressCount int    `json:"in_progress_count"`
+	CompletedCount  int    `json:"completed_count"`
+	LatestStatus    string `json:"latest_status"`
+	Percentage      int    `json:"percentage"`
+}
@@ -0,0 +1,31 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateReportRequest – DTO жалобы на обещание или профиль
+type CreateReportRequest struct {
+	Reason  string `json:"reason" binding:"required,oneof=spam abuse harassment inappropriate other"`
+	Comment string `json:"comment" binding:"max=1000"`
+}
+
+// ResolveReportsRequest – DTO решения модератора по объекту с жалобами
+type ResolveReportsRequest struct {
+	TargetType string    `json:"target_type" binding:"required,oneof=promise user"`
+	TargetID   uuid.UUID `json:"target_id" binding:"required"`
+	Resolution string    `json:"resolution" binding:"required,oneof=dismissed hidden user_suspended"`
+	Note       string    `json:"note" binding:"max=1000"`
+}
+
+// ReportQueueItem – объект в очереди модерации
+type ReportQueueItem struct {
+	TargetType      string    `json:"target_type"`
+	TargetID        uuid.UUID `json:"target_id"`
+	ReportCount     int       `json:"report_count"`
+	Reasons         []string  `json:"reasons"`
+	FirstReportedAt time.Time `json:"first_reported_at"`
+	LastReportedAt  time.Time `json:"last_reported_at"`
+}
@@ -0,0 +1,31 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreatePersonalTokenRequest – DTO создания персонального токена
+type CreatePersonalTokenRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // Не указан – токен бессрочный
+}
+
+// PersonalTokenResponse – DTO персонального токена (без самого значения)
+type PersonalTokenResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// CreatedPersonalTokenResponse – ответ на создание; значение токена показывается один раз
+type CreatedPersonalTokenResponse struct {
+	PersonalTokenResponse
+	Token string `json:"token"`
+}
@@ -1,6 +1,32 @@
 package dto
 
+import "time"
+
 type UpdateUserRequest struct {
 	Username *string `json:"username,omitempty"`
 	Role     *string `json:"role,omitempty"` // Только для админов
 }
+
+// ChangePasswordRequest – DTO смены пароля в профиле
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+	ConfirmPassword string `json:"confirm_password" binding:"required"`
+}
+
+// ChangeEmailRequest – DTO смены email в профиле
+type ChangeEmailRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"` // Текущий пароль
+}
+
+// SuspendUserRequest – DTO временной приостановки аккаунта
+type SuspendUserRequest struct {
+	Reason string    `json:"reason" binding:"required,max=500"`
+	Until  time.Time `json:"until" binding:"required"` // До какого момента (RFC 3339)
+}
+
+// BanUserRequest – DTO бессрочной блокировки аккаунта
+type BanUserRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
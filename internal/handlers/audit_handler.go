@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/internal/dto"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"github.com/raxaris/ipromise-backend/internal/services"
+)
+
+// GetAuditLogsHandler возвращает журнал аудита
+// @Summary Журнал аудита
+// @Description Привилегированные и значимые для безопасности действия: входы, обновления токенов, изменения пользователей и ролей, модерация, удаление обещаний. Фильтр по actor_id находит и действия, выполненные администратором под пользователем
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param actor_id query string false "ID того, кто выполнил действие"
+// @Param action query string false "Действие (user.role.change) или префикс с точкой на конце (user.)"
+// @Param target_type query string false "Тип объекта (user, promise, session, token)"
+// @Param target_id query string false "ID объекта"
+// @Param request_id query string false "ID запроса (X-Request-ID)"
+// @Param ip query string false "IP-адрес"
+// @Param from query string false "Начало периода (RFC 3339)"
+// @Param to query string false "Конец периода (RFC 3339)"
+// @Param limit query int false "Количество записей (до 500)"
+// @Success 200 {array} models.AuditLog
+// @Failure 400 {object} map[string]string "error: Неверные параметры"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /admin/audit [get]
+func GetAuditLogsHandler(c *gin.Context) {
+	filter := dto.AuditLogFilter{
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		RequestID:  c.Query("request_id"),
+		IP:         c.Query("ip"),
+	}
+
+	if raw := c.Query("actor_id"); raw != "" {
+		parsed, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат actor_id"})
+			return
+		}
+		filter.ActorID = &parsed
+	}
+	if raw := c.Query("target_id"); raw != "" {
+		parsed, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат target_id"})
+			return
+		}
+		filter.TargetID = &parsed
+	}
+	if raw := c.Query("from"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Параметр from должен быть в формате RFC 3339"})
+			return
+		}
+		filter.From = &parsed
+	}
+	if raw := c.Query("to"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Параметр to должен быть в формате RFC 3339"})
+			return
+		}
+		filter.To = &parsed
+	}
+	filter.Limit, _ = strconv.Atoi(c.Query("limit"))
+
+	entries, err := services.GetAuditLogs(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения журнала аудита"})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// recordAudit – дополняет запись данными запроса (кто действует, IP, X-Request-ID) и пишет её в журнал аудита.
+// ActorID, заданный вызывающим (например, при входе), не перезаписывается.
+func recordAudit(c *gin.Context, entry models.AuditLog) {
+	if entry.ActorID == nil {
+		if actorID, err := uuid.Parse(c.GetString("user_id")); err == nil {
+			entry.ActorID = &actorID
+		}
+	}
+	if impersonatorID, err := uuid.Parse(c.GetString("impersonator_id")); err == nil {
+		entry.ImpersonatorID = &impersonatorID
+	}
+	entry.IP = c.ClientIP()
+	entry.RequestID = c.GetString("request_id")
+
+	services.RecordAudit(&entry)
+}
@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"errors"
+	"log"
 	"net/http"
-	"time"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -20,7 +22,7 @@ import (
 // @Produce json
 // @Param input body dto.SignupRequest true "Данные для регистрации пользователя"
 // @Success 201 {object} map[string]string "message: Пользователь успешно зарегистрирован"
-// @Failure 400 {object} map[string]string "error: Неверные данные запроса"
+// @Failure 400 {object} map[string]interface{} "error: Неверные данные запроса; violations: нарушения политики паролей"
 // @Failure 409 {object} map[string]string "error: Email или имя пользователя уже занято"
 // @Failure 500 {object} map[string]string "error: Внутренняя ошибка сервера"
 // @Router /auth/signup [post]
@@ -37,6 +39,12 @@ func SignupHandler(c *gin.Context) {
 		return
 	}
 
+	// Проверяем пароль по политике и базе утечек
+	if err := services.ValidatePassword(req.Password, req.Username, req.Email); err != nil {
+		respondPasswordError(c, err)
+		return
+	}
+
 	// Проверяем, существует ли уже email или username
 	var existingUser models.User
 	if err := config.DB.Where("email = ? OR username = ?", req.Email, req.Username).First(&existingUser).Error; err == nil {
@@ -67,19 +75,27 @@ func SignupHandler(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "Пользователь успешно зарегистрирован"})
+	// Отправляем ссылку подтверждения email; при ошибке её можно запросить повторно
+	if err := services.SendEmailVerification(&user); err != nil {
+		log.Println("❌ Ошибка создания ссылки подтверждения email:", err)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Пользователь успешно зарегистрирован, подтвердите email по ссылке из письма"})
 }
 
 // LoginHandler аутентифицирует пользователя
 // @Summary Авторизация пользователя
-// @Description Логин по email и паролю, выдаёт JWT токены
+// @Description Логин по email и паролю, выдаёт JWT токены. Если включена 2FA, возвращает mfa_token для /auth/login/2fa
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param input body dto.LoginRequest true "Данные для входа"
-// @Success 200 {object} map[string]string "access_token: токен, refresh_token: токен"
+// @Success 200 {object} map[string]string "access_token: токен, refresh_token: токен (или mfa_required, mfa_token)"
 // @Failure 400 {object} map[string]string "error: Ошибка валидации"
 // @Failure 401 {object} map[string]string "error: Неверный email или пароль"
+// @Failure 403 {object} map[string]string "error: Аккаунт приостановлен или заблокирован"
+// @Failure 423 {object} map[string]string "error: Аккаунт временно заблокирован"
+// @Failure 429 {object} map[string]string "error: Слишком много попыток с IP"
 // @Failure 500 {object} map[string]string "error: Ошибка сервера"
 // @Router /auth/login [post]
 func LoginHandler(c *gin.Context) {
@@ -89,55 +105,58 @@ func LoginHandler(c *gin.Context) {
 		return
 	}
 
-	var user models.User
-	if err := config.DB.Where("email = ?", req.Email).First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Неверный email или пароль"})
+	// Ограничение перебора: сначала по IP, затем по аккаунту
+	ip := c.ClientIP()
+	if err := services.CheckIPAllowed(ip); err != nil {
+		services.RegisterLoginFailure(nil, req.Email, ip, models.LoginFailureIPThrottled)
+		respondLoginBlocked(c, err)
 		return
 	}
 
-	if !user.CheckPassword(req.Password) {
+	var user models.User
+	if err := config.DB.Where("email = ?", req.Email).First(&user).Error; err != nil {
+		services.RegisterLoginFailure(nil, req.Email, ip, models.LoginFailureUnknownUser)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Неверный email или пароль"})
 		return
 	}
 
-	accessToken, err := services.GenerateAccessToken(user.ID.String(), user.Role)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка генерации Access-токена"})
+	if err := services.CheckAccountLockout(&user); err != nil {
+		services.RegisterLoginFailure(&user, req.Email, ip, models.LoginFailureLocked)
+		respondLoginBlocked(c, err)
 		return
 	}
 
-	refreshToken, err := services.GenerateRefreshToken(user.ID.String(), user.Role)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка генерации Refresh-токена"})
+	if !user.CheckPassword(req.Password) {
+		services.RegisterLoginFailure(&user, req.Email, ip, models.LoginFailureBadPassword)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Неверный email или пароль"})
 		return
 	}
 
-	// Сохраняем Refresh Token в БД
-	refreshTokenEntry := models.RefreshToken{
-		ID:        uuid.New(),
-		UserID:    user.ID,
-		Token:     refreshToken,
-		ExpiresAt: time.Now().Add(7 * 24 * time.Hour),
+	// Причину и срок приостановки сообщаем только тому, кто знает пароль
+	if err := services.CheckAccountActive(&user); err != nil {
+		respondLoginBlocked(c, err)
+		return
 	}
 
-	if err := config.DB.Create(&refreshTokenEntry).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка сохранения Refresh-токена"})
-		return
+	// Хеш по устаревшей политике (bcrypt, старые параметры argon2id) пересчитываем, пока известен пароль
+	if err := services.RehashPasswordIfNeeded(&user, req.Password); err != nil {
+		log.Println("❌ Ошибка пересчёта хеша пароля:", err)
 	}
 
-	c.JSON(http.StatusOK, gin.H{"access_token": accessToken, "refresh_token": refreshToken})
+	completeLogin(c, &user, services.AuthMethodPassword, ip)
 }
 
-// RefreshTokenHandler обновляет Access Token
-// @Summary Обновление Access Token
-// @Description Использует Refresh Token для выдачи нового Access Token
+// RefreshTokenHandler обновляет пару токенов
+// @Summary Обновление токенов
+// @Description Погашает Refresh Token и выдаёт новую пару токенов. Повторное использование погашенного токена отзывает всю сессию
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param input body dto.RefreshTokenRequest true "Refresh Token"
-// @Success 200 {object} map[string]string "access_token: новый access-токен"
+// @Success 200 {object} map[string]string "access_token: новый access-токен, refresh_token: новый refresh-токен"
 // @Failure 400 {object} map[string]string "error: Ошибка валидации"
 // @Failure 401 {object} map[string]string "error: Недействительный Refresh-токен"
+// @Failure 403 {object} map[string]string "error: Аккаунт приостановлен или заблокирован"
 // @Failure 500 {object} map[string]string "error: Ошибка сервера"
 // @Router /auth/refresh [post]
 func RefreshTokenHandler(c *gin.Context) {
@@ -147,10 +166,17 @@ func RefreshTokenHandler(c *gin.Context) {
 		return
 	}
 
-	// Проверяем `refresh_token` через сервис
-	refreshToken, err := services.ValidateRefreshTokenFromDB(config.DB, req.RefreshToken)
+	// Погашаем предъявленный `refresh_token` и получаем следующий в семействе
+	refreshToken, newRefreshToken, err := services.RotateRefreshToken(config.DB, req.RefreshToken, sessionInfo(c))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Недействительный или истёкший Refresh-токен"})
+		switch {
+		case errors.Is(err, services.ErrRefreshTokenReused):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh-токен уже использован, сессия завершена"})
+		case errors.Is(err, services.ErrInvalidRefreshToken):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Недействительный или истёкший Refresh-токен"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка обновления Refresh-токена"})
+		}
 		return
 	}
 
@@ -161,14 +187,139 @@ func RefreshTokenHandler(c *gin.Context) {
 		return
 	}
 
+	// Приостановленному или заблокированному аккаунту новые токены не выдаём
+	var restricted *services.AccountRestrictedError
+	if errors.As(services.CheckAccountActive(user), &restricted) {
+		respondAccountRestricted(c, restricted)
+		return
+	}
+
 	// Генерируем новый `access_token` с `role`
-	newAccessToken, err := services.GenerateAccessToken(user.ID.String(), user.Role)
+	newAccessToken, err := services.GenerateAccessToken(user, refreshToken.AMR())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка генерации нового Access-токена"})
 		return
 	}
+	recordAudit(c, models.AuditLog{ActorID: &user.ID, Action: models.AuditAuthRefresh, TargetType: models.AuditTargetSession, TargetID: &refreshToken.FamilyID})
 
 	c.JSON(http.StatusOK, gin.H{
-		"access_token": newAccessToken,
+		"access_token":  newAccessToken,
+		"refresh_token": newRefreshToken,
 	})
 }
+
+// LogoutHandler завершает текущую сессию
+// @Summary Выход из аккаунта
+// @Description Отзывает предъявленный Refresh Token вместе со всей его сессией
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body dto.RefreshTokenRequest true "Refresh Token"
+// @Success 200 {object} map[string]string "message: Сессия завершена"
+// @Failure 400 {object} map[string]string "error: Ошибка валидации"
+// @Failure 401 {object} map[string]string "error: Недействительный Refresh-токен"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /auth/logout [post]
+func LogoutHandler(c *gin.Context) {
+	var req dto.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := services.Logout(req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRefreshToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Недействительный или истёкший Refresh-токен"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка завершения сессии"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Сессия завершена"})
+}
+
+// respondWithTokens – открывает сессию и отдаёт пару токенов
+func respondWithTokens(c *gin.Context, user *models.User, amr []string) {
+	info := sessionInfo(c)
+	info.AuthMethods = amr
+
+	// Каждый вход открывает новое семейство Refresh-токенов
+	accessToken, refreshToken, err := services.IssueTokenPair(user, info)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка генерации токенов"})
+		return
+	}
+	recordAudit(c, models.AuditLog{ActorID: &user.ID, Action: models.AuditAuthLogin, TargetType: models.AuditTargetUser, TargetID: &user.ID,
+		Changes: models.AuditChanges{"amr": {To: amr}}})
+
+	response := gin.H{"access_token": accessToken, "refresh_token": refreshToken}
+	// Роль требует 2FA, но она не настроена – клиенту стоит предложить включить её
+	if services.IsMFARequired(user.Role) && !user.IsTOTPEnabled() {
+		response["mfa_enrollment_required"] = true
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// completeLogin – завершает вход после первого фактора: при включённой 2FA выдаёт
+// короткоживущий MFA-токен для второго шага, иначе – пару токенов
+func completeLogin(c *gin.Context, user *models.User, firstFactor, ip string) {
+	if user.IsTOTPEnabled() {
+		mfaToken, err := services.GenerateMFAChallenge(user, firstFactor)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка генерации MFA-токена"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"mfa_required": true, "mfa_token": mfaToken})
+		return
+	}
+
+	services.RegisterLoginSuccess(user, ip)
+	respondWithTokens(c, user, []string{firstFactor})
+}
+
+// respondLoginBlocked – 429 при ограничении по IP, 423 при блокировке аккаунта (с заголовком Retry-After),
+// 403 для приостановленного или заблокированного модерацией аккаунта
+func respondLoginBlocked(c *gin.Context, err error) {
+	var restricted *services.AccountRestrictedError
+	if errors.As(err, &restricted) {
+		respondAccountRestricted(c, restricted)
+		return
+	}
+
+	var blocked *services.LoginBlockedError
+	if !errors.As(err, &blocked) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка проверки ограничений входа"})
+		return
+	}
+
+	seconds := retryAfterSeconds(blocked.RetryAfter)
+	c.Header("Retry-After", strconv.Itoa(seconds))
+
+	status := http.StatusTooManyRequests
+	if errors.Is(err, services.ErrAccountLocked) {
+		status = http.StatusLocked
+	}
+	c.JSON(status, gin.H{"error": blocked.Error(), "retry_after": seconds})
+}
+
+// respondAccountRestricted – 403 с причиной и сроком приостановки (suspended_until нет у бессрочного бана)
+func respondAccountRestricted(c *gin.Context, restricted *services.AccountRestrictedError) {
+	body := gin.H{"error": restricted.Error()}
+	if restricted.Comment != "" {
+		body["reason"] = restricted.Comment
+	}
+	if restricted.Until != nil {
+		body["suspended_until"] = restricted.Until
+	}
+	c.JSON(http.StatusForbidden, body)
+}
+
+// sessionInfo – собирает данные клиента для сохранения в сессии
+func sessionInfo(c *gin.Context) services.SessionInfo {
+	return services.SessionInfo{
+		UserAgent: c.Request.UserAgent(),
+		IP:        c.ClientIP(),
+	}
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/internal/dto"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"github.com/raxaris/ipromise-backend/internal/services"
+)
+
+// VerifyEmailHandler подтверждает email по токену из письма
+// @Summary Подтверждение email
+// @Description Подтверждает адрес по одноразовому токену из письма. Для смены email – переключает аккаунт на новый адрес
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body dto.VerifyEmailRequest true "Токен из письма"
+// @Success 200 {object} map[string]string "message: Email подтверждён"
+// @Failure 400 {object} map[string]string "error: Недействительный токен"
+// @Failure 409 {object} map[string]string "error: Email уже используется"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /auth/verify-email [post]
+func VerifyEmailHandler(c *gin.Context) {
+	var req dto.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := services.VerifyEmail(req.Token)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidVerificationToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrEmailTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка подтверждения email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email подтверждён"})
+}
+
+// ResendVerificationHandler повторно отправляет письмо подтверждения
+// @Summary Повторная отправка письма подтверждения
+// @Description Отправляет новую ссылку подтверждения на email текущего пользователя; прежние ссылки перестают действовать. Число писем за окно ограничено (заголовок Retry-After)
+// @Tags auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]string "message: Письмо отправлено"
+// @Failure 409 {object} map[string]string "error: Email уже подтверждён"
+// @Failure 429 {object} map[string]string "error: Слишком много писем подтверждения"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /auth/verify-email/resend [post]
+func ResendVerificationHandler(c *gin.Context) {
+	userID, _ := uuid.Parse(c.GetString("user_id"))
+
+	err := services.ResendEmailVerification(userID)
+	if err != nil {
+		var throttled *services.MailThrottledError
+		switch {
+		case errors.As(err, &throttled):
+			respondMailThrottled(c, throttled)
+		case errors.Is(err, services.ErrEmailAlreadyVerified):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка отправки письма"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Письмо с новой ссылкой отправлено"})
+}
+
+// ChangeEmailHandler начинает смену email
+// @Summary Смена email
+// @Description Проверяет текущий пароль, отправляет ссылку подтверждения на новый адрес и уведомление на старый. Email меняется после перехода по ссылке
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body dto.ChangeEmailRequest true "Новый email и текущий пароль"
+// @Success 202 {object} map[string]string "message: Письмо для подтверждения отправлено"
+// @Failure 400 {object} map[string]string "error: Ошибка валидации или email совпадает с текущим"
+// @Failure 401 {object} map[string]string "error: Неверный текущий пароль"
+// @Failure 403 {object} map[string]string "error: Аккаунт приостановлен или заблокирован"
+// @Failure 409 {object} map[string]string "error: Email уже используется"
+// @Failure 423 {object} map[string]string "error: Аккаунт временно заблокирован"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /profile/email [post]
+func ChangeEmailHandler(c *gin.Context) {
+	var req dto.ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := uuid.Parse(c.GetString("user_id"))
+
+	err := services.RequestEmailChange(userID, req.Email, req.Password, c.ClientIP())
+	if err != nil {
+		var blocked *services.LoginBlockedError
+		var restricted *services.AccountRestrictedError
+		switch {
+		case errors.As(err, &blocked), errors.As(err, &restricted):
+			respondLoginBlocked(c, err)
+		case errors.Is(err, services.ErrInvalidCurrentPassword):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrSameEmail):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrEmailTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка смены email"})
+		}
+		return
+	}
+	recordAudit(c, models.AuditLog{Action: models.AuditEmailChangeRequest, TargetType: models.AuditTargetUser, TargetID: &userID,
+		Changes: models.AuditChanges{"email": {To: req.Email}}})
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Подтвердите новый email по ссылке из письма"})
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/internal/middleware"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"github.com/raxaris/ipromise-backend/internal/services"
+)
+
+// ImpersonateUserHandler выдаёт токен для работы от имени пользователя
+// @Summary Вход под пользователем
+// @Description Выдаёт короткоживущий Access-токен пользователя с claim act (ID администратора), без Refresh-токена. Под администраторами войти нельзя. Все запросы по токену записываются в журнал имперсонации; смена учётных данных, сессии, 2FA и персональные токены по нему недоступны
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "ID пользователя"
+// @Success 200 {object} services.Impersonation
+// @Failure 400 {object} map[string]string "error: Неверный формат ID"
+// @Failure 403 {object} map[string]string "error: Нельзя войти под аккаунтом администратора"
+// @Failure 404 {object} map[string]string "error: Пользователь не найден"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /admin/users/{id}/impersonate [post]
+func ImpersonateUserHandler(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID"})
+		return
+	}
+
+	impersonation, err := services.ImpersonateUser(middleware.Actor(c), userID, c.GetStringSlice("amr"), c.ClientIP())
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrCannotImpersonateSelf):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrCannotImpersonateStaff):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Пользователь не найден"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка выдачи токена"})
+		}
+		return
+	}
+	recordAudit(c, models.AuditLog{Action: models.AuditUserImpersonate, TargetType: models.AuditTargetUser, TargetID: &userID,
+		Changes: models.AuditChanges{"expires_at": {To: impersonation.ExpiresAt}}})
+
+	c.JSON(http.StatusOK, impersonation)
+}
+
+// EndImpersonationHandler досрочно завершает имперсонацию
+// @Summary Завершение входа под пользователем
+// @Description Отзывает токен имперсонации по jti (token_id из журнала имперсонации) до истечения срока
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param token_id path string true "jti токена имперсонации"
+// @Success 200 {object} map[string]string "message: Имперсонация завершена"
+// @Failure 404 {object} map[string]string "error: Токен имперсонации не найден"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /admin/impersonations/{token_id} [delete]
+func EndImpersonationHandler(c *gin.Context) {
+	endImpersonation(c, c.Param("token_id"))
+}
+
+// EndOwnImpersonationHandler завершает имперсонацию, по токену которой выполнен запрос
+// @Summary Выход из аккаунта пользователя
+// @Description Администратор, вошедший под пользователем, отзывает свой токен имперсонации
+// @Tags users
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} map[string]string "message: Имперсонация завершена"
+// @Failure 400 {object} map[string]string "error: Запрос выполнен не по токену имперсонации"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /profile/impersonation [delete]
+func EndOwnImpersonationHandler(c *gin.Context) {
+	tokenID := c.GetString("impersonation_token_id")
+	if tokenID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Запрос выполнен не по токену имперсонации"})
+		return
+	}
+	endImpersonation(c, tokenID)
+}
+
+// endImpersonation – отзыв токена имперсонации с записью в журнал аудита
+func endImpersonation(c *gin.Context, tokenID string) {
+	started, err := services.EndImpersonation(tokenID, c.ClientIP())
+	if err != nil {
+		if errors.Is(err, services.ErrImpersonationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка завершения имперсонации"})
+		return
+	}
+	entry := models.AuditLog{Action: models.AuditImpersonationEnd, TargetType: models.AuditTargetUser, TargetID: &started.UserID,
+		Changes: models.AuditChanges{"token_id": {To: tokenID}}}
+	recordAudit(c, entry)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Имперсонация завершена"})
+}
+
+// GetImpersonationLogsHandler возвращает журнал имперсонации
+// @Summary Журнал имперсонации
+// @Description Выдачи токенов имперсонации и запросы, выполненные по ним, с фильтрами по администратору, пользователю и токену
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param impersonator_id query string false "ID администратора"
+// @Param user_id query string false "ID пользователя"
+// @Param token_id query string false "jti токена имперсонации"
+// @Param limit query int false "Количество записей (до 500)"
+// @Success 200 {array} models.ImpersonationLog
+// @Failure 400 {object} map[string]string "error: Неверные параметры"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /admin/impersonation-log [get]
+func GetImpersonationLogsHandler(c *gin.Context) {
+	var impersonatorID, userID *uuid.UUID
+	if raw := c.Query("impersonator_id"); raw != "" {
+		parsed, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID администратора"})
+			return
+		}
+		impersonatorID = &parsed
+	}
+	if raw := c.Query("user_id"); raw != "" {
+		parsed, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID пользователя"})
+			return
+		}
+		userID = &parsed
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	entries, err := services.GetImpersonationLogs(impersonatorID, userID, c.Query("token_id"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения журнала"})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
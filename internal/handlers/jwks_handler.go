@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raxaris/ipromise-backend/internal/services"
+)
+
+// JWKSHandler отдаёт публичные ключи проверки JWT
+// @Summary Набор публичных ключей (JWKS)
+// @Description Публичные ключи, которыми другие сервисы могут проверять Access-токены. Ключ выбирается по заголовку kid
+// @Tags auth
+// @Produce json
+// @Success 200 {object} map[string][]services.JWK "keys: список ключей"
+// @Router /.well-known/jwks.json [get]
+func JWKSHandler(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{"keys": services.GetJWKS()})
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/internal/dto"
+	"github.com/raxaris/ipromise-backend/internal/pagination"
+)
+
+// Статусы обещаний, по которым можно фильтровать списки
+var promiseStatuses = []string{"pending", "in_progress", "completed"}
+
+// parsePage – limit, sort, order и cursor из query; первое из sorts – сортировка по умолчанию
+func parsePage(c *gin.Context, sorts ...string) (pagination.Page, error) {
+	page := pagination.Page{Limit: pagination.DefaultLimit, Sort: sorts[0], Desc: true}
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > pagination.MaxLimit {
+			return page, pagination.ErrInvalidLimit
+		}
+		page.Limit = limit
+	}
+	if raw := c.Query("sort"); raw != "" {
+		if !slices.Contains(sorts, raw) {
+			return page, pagination.ErrInvalidSort
+		}
+		page.Sort = raw
+	}
+	switch c.Query("order") {
+	case "", "desc":
+	case "asc":
+		page.Desc = false
+	default:
+		return page, errors.New("order должен быть asc или desc")
+	}
+
+	if raw := c.Query("cursor"); raw != "" {
+		cursor, err := pagination.Decode(raw, page.Sort, page.Desc)
+		if err != nil {
+			return page, err
+		}
+		page.After = cursor
+	}
+	return page, nil
+}
+
+// parsePromiseFilter – фильтры списков обещаний: status, owner_id, deadline_from, deadline_to, parent_id (none – только основные)
+func parsePromiseFilter(c *gin.Context) (dto.PromiseFilter, error) {
+	var filter dto.PromiseFilter
+
+	if status := c.Query("status"); status != "" {
+		if !slices.Contains(promiseStatuses, status) {
+			return filter, errors.New("status должен быть одним из: pending, in_progress, completed")
+		}
+		filter.Status = status
+	}
+	if raw := c.Query("owner_id"); raw != "" {
+		ownerID, err := uuid.Parse(raw)
+		if err != nil {
+			return filter, errors.New("неверный формат owner_id")
+		}
+		filter.OwnerID = &ownerID
+	}
+	if raw := c.Query("deadline_from"); raw != "" {
+		from, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filter, errors.New("deadline_from должен быть в формате RFC 3339")
+		}
+		filter.DeadlineFrom = &from
+	}
+	if raw := c.Query("deadline_to"); raw != "" {
+		to, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filter, errors.New("deadline_to должен быть в формате RFC 3339")
+		}
+		filter.DeadlineTo = &to
+	}
+	switch raw := c.Query("parent_id"); raw {
+	case "":
+	case "none":
+		filter.RootOnly = true
+	default:
+		parentID, err := uuid.Parse(raw)
+		if err != nil {
+			return filter, errors.New("неверный формат parent_id")
+		}
+		filter.ParentID = &parentID
+	}
+	return filter, nil
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/internal/services"
+)
+
+// GetLoginAttemptsHandler возвращает журнал попыток входа
+// @Summary Журнал попыток входа
+// @Description Последние попытки входа с фильтрами по email, IP, пользователю и результату
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param email query string false "Email"
+// @Param ip query string false "IP-адрес"
+// @Param user_id query string false "ID пользователя"
+// @Param failed query bool false "Только неудачные"
+// @Param limit query int false "Количество записей (до 500)"
+// @Success 200 {array} models.LoginAttempt
+// @Failure 400 {object} map[string]string "error: Неверные параметры"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /admin/login-attempts [get]
+func GetLoginAttemptsHandler(c *gin.Context) {
+	var userID *uuid.UUID
+	if raw := c.Query("user_id"); raw != "" {
+		parsed, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID пользователя"})
+			return
+		}
+		userID = &parsed
+	}
+
+	onlyFailed, _ := strconv.ParseBool(c.Query("failed"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	attempts, err := services.GetLoginAttempts(c.Query("email"), c.Query("ip"), userID, onlyFailed, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения попыток входа"})
+		return
+	}
+
+	c.JSON(http.StatusOK, attempts)
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raxaris/ipromise-backend/internal/dto"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"github.com/raxaris/ipromise-backend/internal/services"
+)
+
+// RequestMagicLinkHandler отправляет ссылку для входа без пароля
+// @Summary Запрос ссылки для входа
+// @Description Отправляет на email одноразовую ссылку входа. Ответ не зависит от того, зарегистрирован ли email
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body dto.MagicLinkRequest true "Email аккаунта"
+// @Success 200 {object} map[string]string "message: Если аккаунт существует, письмо отправлено"
+// @Failure 400 {object} map[string]string "error: Ошибка валидации"
+// @Failure 429 {object} map[string]string "error: Слишком много запросов с IP"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /auth/magic-link [post]
+func RequestMagicLinkHandler(c *gin.Context) {
+	var req dto.MagicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := services.RequestMagicLink(req.Email, c.ClientIP()); err != nil {
+		var throttled *services.MailThrottledError
+		if errors.As(err, &throttled) {
+			respondMailThrottled(c, throttled)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка создания ссылки для входа"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Если аккаунт существует, письмо со ссылкой для входа отправлено"})
+}
+
+// MagicLinkLoginHandler выдаёт токены по ссылке из письма
+// @Summary Вход по ссылке
+// @Description Обменивает одноразовый токен из письма на JWT токены. Если включена 2FA, возвращает mfa_token для /auth/login/2fa
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body dto.MagicLinkLoginRequest true "Токен из ссылки"
+// @Success 200 {object} map[string]string "access_token: токен, refresh_token: токен (или mfa_required, mfa_token)"
+// @Failure 400 {object} map[string]string "error: Ошибка валидации"
+// @Failure 401 {object} map[string]string "error: Ссылка недействительна или устарела"
+// @Failure 423 {object} map[string]string "error: Аккаунт временно заблокирован"
+// @Failure 429 {object} map[string]string "error: Слишком много попыток с IP"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /auth/magic-link/login [post]
+func MagicLinkLoginHandler(c *gin.Context) {
+	var req dto.MagicLinkLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ip := c.ClientIP()
+	if err := services.CheckIPAllowed(ip); err != nil {
+		services.RegisterLoginFailure(nil, "", ip, models.LoginFailureIPThrottled)
+		respondLoginBlocked(c, err)
+		return
+	}
+
+	user, err := services.CompleteMagicLinkLogin(req.Token, ip)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidMagicLink) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка входа"})
+		return
+	}
+
+	if err := services.CheckAccountAllowed(user); err != nil {
+		services.RegisterLoginFailure(user, user.Email, ip, models.LoginFailureLocked)
+		respondLoginBlocked(c, err)
+		return
+	}
+
+	// Ссылка заменяет только пароль – включённая 2FA всё равно требуется
+	completeLogin(c, user, services.AuthMethodMagicLink, ip)
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/internal/dto"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"github.com/raxaris/ipromise-backend/internal/services"
+)
+
+// SetupTOTPHandler начинает настройку двухфакторной аутентификации
+// @Summary Настройка 2FA
+// @Description Создаёт секрет TOTP и возвращает otpauth-ссылку и QR-код (PNG в base64). 2FA включится после подтверждения кодом
+// @Tags 2fa
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} dto.TOTPSetupResponse
+// @Failure 409 {object} map[string]string "error: 2FA уже включена"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /profile/2fa/setup [post]
+func SetupTOTPHandler(c *gin.Context) {
+	userID, _ := uuid.Parse(c.GetString("user_id"))
+
+	setup, err := services.SetupTOTP(userID)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, setup)
+}
+
+// ConfirmTOTPHandler включает двухфакторную аутентификацию
+// @Summary Подтверждение 2FA
+// @Description Проверяет первый код из приложения, включает 2FA и возвращает коды восстановления (показываются один раз)
+// @Tags 2fa
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body dto.TOTPCodeRequest true "Код из приложения"
+// @Success 200 {object} dto.RecoveryCodesResponse
+// @Failure 400 {object} map[string]string "error: Неверный код"
+// @Failure 409 {object} map[string]string "error: 2FA уже включена"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /profile/2fa/confirm [post]
+func ConfirmTOTPHandler(c *gin.Context) {
+	var req dto.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := uuid.Parse(c.GetString("user_id"))
+
+	codes, err := services.ConfirmTOTP(userID, req.Code)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditLog{Action: models.AuditMFAEnable, TargetType: models.AuditTargetUser, TargetID: &userID})
+
+	c.JSON(http.StatusOK, dto.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTOTPHandler выключает двухфакторную аутентификацию
+// @Summary Отключение 2FA
+// @Description Выключает 2FA после проверки кода из приложения или кода восстановления
+// @Tags 2fa
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body dto.TOTPCodeRequest true "Код из приложения или код восстановления"
+// @Success 200 {object} map[string]string "message: 2FA отключена"
+// @Failure 400 {object} map[string]string "error: Неверный код"
+// @Failure 403 {object} map[string]string "error: 2FA обязательна для роли"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /profile/2fa [delete]
+func DisableTOTPHandler(c *gin.Context) {
+	var req dto.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := uuid.Parse(c.GetString("user_id"))
+
+	if err := services.DisableTOTP(userID, req.Code); err != nil {
+		respondMFAError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditLog{Action: models.AuditMFADisable, TargetType: models.AuditTargetUser, TargetID: &userID})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Двухфакторная аутентификация отключена"})
+}
+
+// RegenerateRecoveryCodesHandler выдаёт новые коды восстановления
+// @Summary Новые коды восстановления
+// @Description Заменяет коды восстановления новым набором после проверки кода
+// @Tags 2fa
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body dto.TOTPCodeRequest true "Код из приложения или код восстановления"
+// @Success 200 {object} dto.RecoveryCodesResponse
+// @Failure 400 {object} map[string]string "error: Неверный код"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /profile/2fa/recovery-codes [post]
+func RegenerateRecoveryCodesHandler(c *gin.Context) {
+	var req dto.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := uuid.Parse(c.GetString("user_id"))
+
+	codes, err := services.RegenerateRecoveryCodes(userID, req.Code)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditLog{Action: models.AuditMFARecoveryCodes, TargetType: models.AuditTargetUser, TargetID: &userID})
+
+	c.JSON(http.StatusOK, dto.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// LoginMFAHandler завершает вход с двухфакторной аутентификацией
+// @Summary Второй шаг входа (2FA)
+// @Description Принимает mfa_token из /auth/login и код TOTP или код восстановления, выдаёт JWT токены
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body dto.MFALoginRequest true "MFA-токен и код"
+// @Success 200 {object} map[string]string "access_token: токен, refresh_token: токен"
+// @Failure 400 {object} map[string]string "error: Ошибка валидации"
+// @Failure 401 {object} map[string]string "error: Неверный код или MFA-токен"
+// @Failure 423 {object} map[string]string "error: Аккаунт временно заблокирован"
+// @Failure 429 {object} map[string]string "error: Слишком много попыток с IP"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /auth/login/2fa [post]
+func LoginMFAHandler(c *gin.Context) {
+	var req dto.MFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ip := c.ClientIP()
+	if err := services.CheckIPAllowed(ip); err != nil {
+		respondLoginBlocked(c, err)
+		return
+	}
+
+	user, amr, err := services.CompleteMFALogin(req.MFAToken, req.Code, ip)
+	if err != nil {
+		var blocked *services.LoginBlockedError
+		var restricted *services.AccountRestrictedError
+		if errors.As(err, &blocked) || errors.As(err, &restricted) {
+			respondLoginBlocked(c, err)
+			return
+		}
+		if errors.Is(err, services.ErrInvalidMFAToken) || errors.Is(err, services.ErrInvalidMFACode) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка входа"})
+		return
+	}
+
+	respondWithTokens(c, user, amr)
+}
+
+// respondMFAError – ответ на ошибку управления 2FA
+func respondMFAError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidMFACode), errors.Is(err, services.ErrTOTPNotSetUp):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrTOTPAlreadyEnabled), errors.Is(err, services.ErrTOTPNotEnabled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrTOTPRequired):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Пользователь не найден"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка двухфакторной аутентификации"})
+	}
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/internal/dto"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"github.com/raxaris/ipromise-backend/internal/services"
+)
+
+// GetRecentPublicPromisesHandler возвращает свежие публичные обещания для модерации
+// @Summary Лента модерации
+// @Description Последние публичные обещания, включая уже скрытые. Приватные обещания не показываются
+// @Tags moderation
+// @Security BearerAuth
+// @Produce json
+// @Param since query string false "Не раньше этого времени (RFC 3339)"
+// @Param limit query int false "Количество записей (до 200)"
+// @Success 200 {array} models.Promise
+// @Failure 400 {object} map[string]string "error: Неверные параметры"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /moderation/promises [get]
+func GetRecentPublicPromisesHandler(c *gin.Context) {
+	var since *time.Time
+	if raw := c.Query("since"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат since, нужен RFC 3339"})
+			return
+		}
+		since = &parsed
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	promises, err := services.GetRecentPublicPromises(since, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения обещаний"})
+		return
+	}
+
+	c.JSON(http.StatusOK, promises)
+}
+
+// HidePromiseHandler скрывает публичное обещание
+// @Summary Скрыть обещание
+// @Description Убирает публичное обещание из общих списков. Автор продолжает его видеть
+// @Tags moderation
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "ID обещания"
+// @Param input body dto.HidePromiseRequest true "Причина"
+// @Success 200 {object} map[string]string "message: Обещание скрыто"
+// @Failure 400 {object} map[string]string "error: Ошибка валидации"
+// @Failure 404 {object} map[string]string "error: Обещание не найдено"
+// @Failure 409 {object} map[string]string "error: Обещание уже скрыто"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /moderation/promises/{id}/hide [post]
+func HidePromiseHandler(c *gin.Context) {
+	promiseID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID обещания"})
+		return
+	}
+
+	var req dto.HidePromiseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	moderatorID, _ := uuid.Parse(c.GetString("user_id"))
+
+	if err := services.HidePromise(moderatorID, promiseID, req.Reason); err != nil {
+		respondModerationError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditLog{Action: models.AuditPromiseHide, TargetType: models.AuditTargetPromise, TargetID: &promiseID,
+		Changes: models.AuditChanges{"hidden_reason": {To: req.Reason}}})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Обещание скрыто"})
+}
+
+// UnhidePromiseHandler возвращает обещание в публичные списки
+// @Summary Вернуть обещание
+// @Description Снимает скрытие с публичного обещания
+// @Tags moderation
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "ID обещания"
+// @Success 200 {object} map[string]string "message: Обещание снова видно"
+// @Failure 400 {object} map[string]string "error: Неверный формат ID"
+// @Failure 404 {object} map[string]string "error: Обещание не найдено"
+// @Failure 409 {object} map[string]string "error: Обещание не скрыто"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /moderation/promises/{id}/unhide [post]
+func UnhidePromiseHandler(c *gin.Context) {
+	promiseID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID обещания"})
+		return
+	}
+
+	if err := services.UnhidePromise(promiseID); err != nil {
+		respondModerationError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditLog{Action: models.AuditPromiseUnhide, TargetType: models.AuditTargetPromise, TargetID: &promiseID})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Обещание снова видно"})
+}
+
+// ModeratePromiseTitleHandler заменяет заголовок публичного обещания
+// @Summary Исправить заголовок
+// @Description Заменяет недопустимый заголовок публичного обещания
+// @Tags moderation
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "ID обещания"
+// @Param input body dto.ModeratePromiseTitleRequest true "Новый заголовок"
+// @Success 200 {object} map[string]string "message: Заголовок изменён"
+// @Failure 400 {object} map[string]string "error: Ошибка валидации"
+// @Failure 404 {object} map[string]string "error: Обещание не найдено"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /moderation/promises/{id}/title [put]
+func ModeratePromiseTitleHandler(c *gin.Context) {
+	promiseID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID обещания"})
+		return
+	}
+
+	var req dto.ModeratePromiseTitleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	changes, err := services.ModeratePromiseTitle(promiseID, req.Title)
+	if err != nil {
+		respondModerationError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditLog{Action: models.AuditPromiseTitleEdit, TargetType: models.AuditTargetPromise, TargetID: &promiseID, Changes: changes})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Заголовок изменён"})
+}
+
+// respondModerationError – сопоставляет ошибки модерации с HTTP-статусами
+func respondModerationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrPromiseNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrPromiseAlreadyHidden), errors.Is(err, services.ErrPromiseNotHidden):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidTitle):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка модерации"})
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/raxaris/ipromise-backend/config"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"github.com/raxaris/ipromise-backend/internal/oidc"
+	"github.com/raxaris/ipromise-backend/internal/services"
+)
+
+// Cookie, к которой привязан state входа через провайдера
+const (
+	oidcStateCookie = "oidc_state"
+	oidcCookiePath  = "/auth/oidc"
+)
+
+// secureCookies – cookie только по HTTPS, если сервис доступен по HTTPS
+func secureCookies() bool {
+	return strings.HasPrefix(config.AppBaseURL, "https://")
+}
+
+// GetOIDCProvidersHandler возвращает настроенных OIDC-провайдеров
+// @Summary Провайдеры входа через внешний аккаунт
+// @Description Имена провайдеров для /auth/oidc/{provider}/login
+// @Tags auth
+// @Produce json
+// @Success 200 {object} map[string][]string "providers: список провайдеров"
+// @Router /auth/oidc [get]
+func GetOIDCProvidersHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": services.GetOIDCProviderNames()})
+}
+
+// OIDCLoginHandler начинает вход через внешнего провайдера
+// @Summary Вход через OIDC-провайдера
+// @Description Перенаправляет на страницу входа провайдера (authorization code + PKCE) и сохраняет state в cookie oidc_state
+// @Tags auth
+// @Param provider path string true "Имя провайдера"
+// @Success 302 "Редирект к провайдеру"
+// @Failure 404 {object} map[string]string "error: Неизвестный провайдер"
+// @Failure 502 {object} map[string]string "error: Провайдер недоступен"
+// @Router /auth/oidc/{provider}/login [get]
+func OIDCLoginHandler(c *gin.Context) {
+	authURL, state, err := services.StartOIDCLogin(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		respondOIDCError(c, err)
+		return
+	}
+
+	// Lax: cookie уходит при возврате от провайдера (переход верхнего уровня), но не в запросах с чужих сайтов
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, state, int(services.OIDCStateTTL.Seconds()), oidcCookiePath, "", secureCookies(), true)
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallbackHandler завершает вход через внешнего провайдера
+// @Summary Callback OIDC-провайдера
+// @Description Обменивает код на ID-токен, находит или создаёт пользователя и выдаёт JWT токены. Если включена 2FA, возвращает mfa_token для /auth/login/2fa
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Имя провайдера"
+// @Param state query string true "state из запроса входа"
+// @Param code query string true "Код авторизации"
+// @Success 200 {object} map[string]string "access_token: токен, refresh_token: токен (или mfa_required, mfa_token)"
+// @Failure 400 {object} map[string]string "error: Вход отменён, сессия входа устарела или начата в другом браузере"
+// @Failure 401 {object} map[string]string "error: Недействительный ID-токен"
+// @Failure 409 {object} map[string]string "error: Аккаунт с этим email уже существует"
+// @Failure 423 {object} map[string]string "error: Аккаунт временно заблокирован"
+// @Failure 429 {object} map[string]string "error: Слишком много попыток с IP"
+// @Failure 502 {object} map[string]string "error: Провайдер недоступен"
+// @Router /auth/oidc/{provider}/callback [get]
+func OIDCCallbackHandler(c *gin.Context) {
+	if providerErr := c.Query("error"); providerErr != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Вход через провайдера отменён: " + providerErr})
+		return
+	}
+
+	state, code := c.Query("state"), c.Query("code")
+	if state == "" || code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не переданы state и code"})
+		return
+	}
+
+	ip := c.ClientIP()
+	if err := services.CheckIPAllowed(ip); err != nil {
+		respondLoginBlocked(c, err)
+		return
+	}
+
+	cookieState, _ := c.Cookie(oidcStateCookie)
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, "", -1, oidcCookiePath, "", secureCookies(), true)
+
+	user, err := services.CompleteOIDCLogin(c.Request.Context(), c.Param("provider"), state, cookieState, code)
+	if err != nil {
+		respondOIDCError(c, err)
+		return
+	}
+
+	if err := services.CheckAccountAllowed(user); err != nil {
+		services.RegisterLoginFailure(user, user.Email, ip, models.LoginFailureLocked)
+		respondLoginBlocked(c, err)
+		return
+	}
+
+	// Внешний провайдер заменяет только пароль – включённая 2FA всё равно требуется
+	completeLogin(c, user, services.AuthMethodOIDC, ip)
+}
+
+// respondOIDCError – сопоставляет ошибки входа через провайдера с HTTP-статусами
+func respondOIDCError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrOIDCProviderNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrOIDCInvalidState), errors.Is(err, services.ErrOIDCEmailRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, oidc.ErrInvalidToken):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Недействительный ID-токен провайдера"})
+	case errors.Is(err, services.ErrOIDCEmailConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, oidc.ErrDiscovery), errors.Is(err, oidc.ErrTokenExchange):
+		log.Println("❌ Ошибка OIDC-провайдера:", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Провайдер входа недоступен"})
+	default:
+		log.Println("❌ Ошибка входа через OIDC:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка входа через внешний аккаунт"})
+	}
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/internal/dto"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"github.com/raxaris/ipromise-backend/internal/services"
+)
+
+// ForgotPasswordHandler отправляет ссылку для сброса пароля
+// @Summary Запрос на сброс пароля
+// @Description Отправляет на email одноразовую ссылку для сброса пароля. Ответ не зависит от того, зарегистрирован ли email
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body dto.ForgotPasswordRequest true "Email аккаунта"
+// @Success 200 {object} map[string]string "message: Если аккаунт существует, письмо отправлено"
+// @Failure 400 {object} map[string]string "error: Ошибка валидации"
+// @Failure 429 {object} map[string]string "error: Слишком много запросов с IP"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /auth/password/forgot [post]
+func ForgotPasswordHandler(c *gin.Context) {
+	var req dto.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := services.RequestPasswordReset(req.Email, c.ClientIP()); err != nil {
+		var throttled *services.MailThrottledError
+		if errors.As(err, &throttled) {
+			respondMailThrottled(c, throttled)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка создания ссылки для сброса пароля"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Если аккаунт существует, письмо со ссылкой отправлено"})
+}
+
+// ResetPasswordHandler задаёт новый пароль по токену из письма
+// @Summary Сброс пароля
+// @Description Устанавливает новый пароль по одноразовому токену и завершает все сессии
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body dto.ResetPasswordRequest true "Токен и новый пароль"
+// @Success 200 {object} map[string]string "message: Пароль изменён"
+// @Failure 400 {object} map[string]interface{} "error: Ошибка валидации или недействительный токен; violations: нарушения политики паролей"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /auth/password/reset [post]
+func ResetPasswordHandler(c *gin.Context) {
+	var req dto.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Password != req.ConfirmPassword {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Пароли не совпадают"})
+		return
+	}
+
+	userID, err := services.ResetPassword(req.Token, req.Password)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidResetToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		respondPasswordError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditLog{ActorID: &userID, Action: models.AuditAuthPasswordReset, TargetType: models.AuditTargetUser, TargetID: &userID})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Пароль изменён, войдите заново"})
+}
+
+// ChangePasswordHandler меняет пароль текущего пользователя
+// @Summary Смена пароля
+// @Description Меняет пароль после проверки текущего, завершает все остальные сессии и выдаёт новые токены текущему клиенту
+// @Tags users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body dto.ChangePasswordRequest true "Текущий и новый пароль"
+// @Success 200 {object} map[string]string "access_token: токен, refresh_token: токен"
+// @Failure 400 {object} map[string]interface{} "error: Ошибка валидации; violations: нарушения политики паролей"
+// @Failure 401 {object} map[string]string "error: Неверный текущий пароль"
+// @Failure 403 {object} map[string]string "error: Аккаунт приостановлен или заблокирован"
+// @Failure 423 {object} map[string]string "error: Аккаунт временно заблокирован"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /profile/password [post]
+func ChangePasswordHandler(c *gin.Context) {
+	var req dto.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.NewPassword != req.ConfirmPassword {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Пароли не совпадают"})
+		return
+	}
+
+	userID, _ := uuid.Parse(c.GetString("user_id"))
+
+	info := sessionInfo(c)
+	info.AuthMethods = c.GetStringSlice("amr")
+
+	accessToken, refreshToken, err := services.ChangePassword(userID, req.CurrentPassword, req.NewPassword, info)
+	if err != nil {
+		var blocked *services.LoginBlockedError
+		var restricted *services.AccountRestrictedError
+		switch {
+		case errors.As(err, &blocked), errors.As(err, &restricted):
+			respondLoginBlocked(c, err)
+		case errors.Is(err, services.ErrInvalidCurrentPassword):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrSamePassword):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			respondPasswordError(c, err)
+		}
+		return
+	}
+
+	recordAudit(c, models.AuditLog{Action: models.AuditPasswordChange, TargetType: models.AuditTargetUser, TargetID: &userID})
+
+	c.JSON(http.StatusOK, gin.H{"access_token": accessToken, "refresh_token": refreshToken})
+}
+
+// respondMailThrottled – 429 с заголовком Retry-After, когда исчерпан лимит писем
+func respondMailThrottled(c *gin.Context, throttled *services.MailThrottledError) {
+	seconds := retryAfterSeconds(throttled.RetryAfter)
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": throttled.Error(), "retry_after": seconds})
+}
+
+// retryAfterSeconds – значение Retry-After в целых секундах, не меньше одной
+func retryAfterSeconds(retryAfter time.Duration) int {
+	return max(int(math.Ceil(retryAfter.Seconds())), 1)
+}
+
+// respondPasswordError – 400 со списком нарушений, если пароль не прошёл политику, иначе 500
+func respondPasswordError(c *gin.Context, err error) {
+	var policyErr *services.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      "Пароль не соответствует требованиям",
+			"violations": policyErr.Violations,
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка смены пароля"})
+}
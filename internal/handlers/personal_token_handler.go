@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/internal/dto"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"github.com/raxaris/ipromise-backend/internal/services"
+)
+
+// GetPersonalTokensHandler возвращает персональные токены пользователя
+// @Summary Список персональных токенов
+// @Description Возвращает неотозванные персональные токены (без значений)
+// @Tags tokens
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} dto.PersonalTokenResponse
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /profile/tokens [get]
+func GetPersonalTokensHandler(c *gin.Context) {
+	userID, _ := uuid.Parse(c.GetString("user_id"))
+
+	tokens, err := services.GetPersonalTokens(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения токенов"})
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// CreatePersonalTokenHandler создаёт персональный токен
+// @Summary Создание персонального токена
+// @Description Создаёт токен с именем, scope (promises:read, promises:write) и сроком действия. Значение показывается один раз
+// @Tags tokens
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body dto.CreatePersonalTokenRequest true "Параметры токена"
+// @Success 201 {object} dto.CreatedPersonalTokenResponse
+// @Failure 400 {object} map[string]string "error: Ошибка валидации"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /profile/tokens [post]
+func CreatePersonalTokenHandler(c *gin.Context) {
+	var req dto.CreatePersonalTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := uuid.Parse(c.GetString("user_id"))
+
+	token, err := services.CreatePersonalToken(userID, req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidScope) || errors.Is(err, services.ErrInvalidTokenExpiry) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка создания токена"})
+		return
+	}
+	recordAudit(c, models.AuditLog{Action: models.AuditTokenCreate, TargetType: models.AuditTargetToken, TargetID: &token.ID,
+		Changes: models.AuditChanges{"scopes": {To: token.Scopes}}})
+
+	c.JSON(http.StatusCreated, token)
+}
+
+// RevokePersonalTokenHandler отзывает персональный токен
+// @Summary Отзыв персонального токена
+// @Description Токен перестаёт действовать немедленно
+// @Tags tokens
+// @Security BearerAuth
+// @Param id path string true "ID токена"
+// @Success 200 {object} map[string]string "message: Токен отозван"
+// @Failure 400 {object} map[string]string "error: Неверный формат ID"
+// @Failure 404 {object} map[string]string "error: Токен не найден"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /profile/tokens/{id} [delete]
+func RevokePersonalTokenHandler(c *gin.Context) {
+	userID, _ := uuid.Parse(c.GetString("user_id"))
+
+	tokenID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID токена"})
+		return
+	}
+
+	err = services.RevokePersonalToken(userID, tokenID)
+	if err != nil {
+		if errors.Is(err, services.ErrPersonalTokenMissing) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Токен не найден"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка отзыва токена"})
+		return
+	}
+	recordAudit(c, models.AuditLog{Action: models.AuditTokenRevoke, TargetType: models.AuditTargetToken, TargetID: &tokenID})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Токен отозван"})
+}
@@ -1,12 +1,17 @@
 package handlers
 
 import (
-	"github.com/raxaris/ipromise-backend/internal/models"
+	"errors"
 	"net/http"
 
+	"github.com/raxaris/ipromise-backend/internal/models"
+
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/internal/authz"
 	"github.com/raxaris/ipromise-backend/internal/dto"
+	"github.com/raxaris/ipromise-backend/internal/middleware"
+	"github.com/raxaris/ipromise-backend/internal/pagination"
 	"github.com/raxaris/ipromise-backend/internal/services"
 )
 
@@ -20,6 +25,7 @@ import (
 // @Param input body dto.CreatePromiseRequest true "Данные обещания"
 // @Success 201 {object} map[string]string "message: Обещание успешно создано"
 // @Failure 400 {object} map[string]string "error: Ошибка валидации"
+// @Failure 403 {object} map[string]string "error: Email не подтверждён"
 // @Failure 500 {object} map[string]string "error: Ошибка сервера"
 // @Router /promises [post]
 func CreatePromiseHandler(c *gin.Context) {
@@ -35,6 +41,10 @@ func CreatePromiseHandler(c *gin.Context) {
 	// Создаём обещание
 	err := services.CreatePromise(userID, req)
 	if err != nil {
+		if errors.Is(err, services.ErrEmailNotVerified) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -44,34 +54,88 @@ func CreatePromiseHandler(c *gin.Context) {
 
 // GetAllPromisesHandler получает все обещания
 // @Summary Получение всех обещаний
-// @Description Возвращает список всех обещаний
-// @Tags promises
+// @Description Страница всех обещаний, включая приватные и скрытые (promise.read.any); без этого разрешения – только публичные
+// @Tags admin
 // @Security BearerAuth
-// @Success 200 {array} models.Promise
-// @Router /promises [get]
+// @Produce json
+// @Param limit query int false "Размер страницы (1–100, по умолчанию 20)"
+// @Param cursor query string false "Курсор следующей страницы (next_cursor)"
+// @Param sort query string false "Сортировка: created_at (по умолчанию) или deadline"
+// @Param order query string false "Порядок: desc (по умолчанию) или asc"
+// @Param status query string false "Статус: pending, in_progress, completed"
+// @Param owner_id query string false "ID автора"
+// @Param deadline_from query string false "Дедлайн не раньше (RFC 3339)"
+// @Param deadline_to query string false "Дедлайн раньше (RFC 3339)"
+// @Param parent_id query string false "ID родительского обещания; none – только основные"
+// @Success 200 {object} pagination.Result[models.Promise]
+// @Failure 400 {object} map[string]string "error: Неверные параметры"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /admin/promises [get]
 func GetAllPromisesHandler(c *gin.Context) {
-	isAdmin := c.GetString("role") == "admin"
+	filter, page, ok := promiseListQuery(c)
+	if !ok {
+		return
+	}
+	filter.PublicOnly = !authz.Can(middleware.Actor(c), authz.PromiseRead, nil)
 
-	var promises []models.Promise
-	var err error
+	respondPromiseList(c, filter, page)
+}
 
-	if isAdmin {
-		promises, err = services.GetAllPromises()
-	} else {
-		promises, err = services.GetAllPublicPromises() // 🔹 Только публичные обещания
+// SearchPromisesHandler ищет обещания по тексту
+// @Summary Поиск обещаний
+// @Description Полнотекстовый поиск по заголовку и описанию (русский и английский). Без токена – только публичные обещания;
+// @Description с токеном – ещё и свои, включая приватные. В title_highlight и description_highlight совпадения обёрнуты в <mark>, текст экранирован для HTML.
+// @Tags promises
+// @Produce json
+// @Param q query string true "Поисковый запрос: слова, «фраза», -исключение, or"
+// @Param limit query int false "Размер страницы (1–100, по умолчанию 20)"
+// @Param cursor query string false "Курсор следующей страницы (next_cursor)"
+// @Param sort query string false "Сортировка: rank (по умолчанию) или created_at"
+// @Param order query string false "Порядок: desc (по умолчанию) или asc"
+// @Param status query string false "Статус: pending, in_progress, completed"
+// @Param owner_id query string false "ID автора"
+// @Param deadline_from query string false "Дедлайн не раньше (RFC 3339)"
+// @Param deadline_to query string false "Дедлайн раньше (RFC 3339)"
+// @Param parent_id query string false "ID родительского обещания; none – только основные"
+// @Success 200 {object} pagination.Result[dto.PromiseSearchHit]
+// @Failure 400 {object} map[string]string "error: Неверные параметры"
+// @Failure 401 {object} map[string]string "error: Недействительный токен"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /promises/search [get]
+func SearchPromisesHandler(c *gin.Context) {
+	filter, err := parsePromiseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-
+	page, err := parsePage(c, "rank", "created_at")
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения обещаний"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, promises)
+	// Кто может читать все обещания, ищет по всем; остальные – по публичным и своим
+	actor := middleware.Actor(c)
+	if !authz.Can(actor, authz.PromiseRead, nil) {
+		filter.PublicOnly = true
+		if actor.ID != uuid.Nil {
+			filter.ViewerID = &actor.ID
+		}
+	}
+
+	result, err := services.SearchPromises(c.Query("q"), filter, page)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidSearchQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка поиска обещаний"})
+		return
+	}
+	c.JSON(http.StatusOK, result)
 }
 
 func GetPromiseByIDHandler(c *gin.Context) {
-	userID, _ := uuid.Parse(c.GetString("user_id"))
-	isAdmin := c.GetString("role") == "admin"
 	promiseID, err := uuid.Parse(c.Param("id"))
 
 	if err != nil {
@@ -79,72 +143,144 @@ func GetPromiseByIDHandler(c *gin.Context) {
 		return
 	}
 
-	promise, err := services.GetPromiseByID(promiseID)
+	promise, err := services.GetPromiseByID(middleware.Actor(c), promiseID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Обещание не найдено"})
+		respondPromiseAccessError(c, err)
 		return
 	}
 
-	// ✅ Проверяем доступ: владелец или админ могут видеть обещание
-	if promise.IsPrivate && promise.UserID != userID && !isAdmin {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Это приватное обещание"})
+	c.JSON(http.StatusOK, promise)
+}
+
+// GetPromiseTreeHandler получает обещание с деревом прогресса
+// @Summary Дерево прогресса обещания
+// @Description Основное обещание (для ID записи прогресса – то, к которому она относится) со всеми обновлениями прогресса
+// @Description по порядку создания, их количеством, последним статусом и процентом выполнения. Без токена – только публичное;
+// @Description владелец с токеном видит и приватное. Записи прогресса, недоступные для просмотра, не возвращаются и не учитываются.
+// @Tags promises
+// @Produce json
+// @Param id path string true "ID обещания или записи прогресса"
+// @Success 200 {object} dto.PromiseTree
+// @Failure 400 {object} map[string]string "error: Неверный формат ID"
+// @Failure 403 {object} map[string]string "error: Это приватное обещание"
+// @Failure 404 {object} map[string]string "error: Обещание не найдено"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /promises/{id}/tree [get]
+func GetPromiseTreeHandler(c *gin.Context) {
+	promiseID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID обещания"})
 		return
 	}
 
-	c.JSON(http.StatusOK, promise)
+	tree, err := services.GetPromiseTree(middleware.Actor(c), promiseID)
+	if err != nil {
+		respondPromiseAccessError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, tree)
+}
+
+// respondPromiseAccessError – ответ на ошибку получения обещания: приватное – 403, нет или скрыто – 404
+func respondPromiseAccessError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrPromisePrivate):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrPromiseNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения обещания"})
+	}
 }
 
 // GetUserPromisesHandler получает список обещаний пользователя
-// @Summary Получение обещаний пользователя
-// @Description Возвращает список обещаний пользователя по его ID
+// @Summary Получение своих обещаний
+// @Description Страница обещаний текущего пользователя, включая приватные. Персональные токены – со scope promises:read
 // @Tags promises
 // @Security BearerAuth
-// @Param id path string true "ID пользователя"
-// @Success 200 {array} models.Promise
-// @Failure 400 {object} map[string]string "error: Неверный формат ID пользователя"
-// @Failure 404 {object} map[string]string "error: Пользователь не найден"
+// @Produce json
+// @Param limit query int false "Размер страницы (1–100, по умолчанию 20)"
+// @Param cursor query string false "Курсор следующей страницы (next_cursor)"
+// @Param sort query string false "Сортировка: created_at (по умолчанию) или deadline"
+// @Param order query string false "Порядок: desc (по умолчанию) или asc"
+// @Param status query string false "Статус: pending, in_progress, completed"
+// @Param deadline_from query string false "Дедлайн не раньше (RFC 3339)"
+// @Param deadline_to query string false "Дедлайн раньше (RFC 3339)"
+// @Param parent_id query string false "ID родительского обещания; none – только основные"
+// @Success 200 {object} pagination.Result[models.Promise]
+// @Failure 400 {object} map[string]string "error: Неверные параметры"
 // @Failure 500 {object} map[string]string "error: Ошибка получения обещаний"
-// @Router /users/{id}/promises [get]
+// @Router /profile/promises [get]
 func GetUserPromisesHandler(c *gin.Context) {
-	requestedUserID, err := uuid.Parse(c.Param("id")) // ID пользователя, чьи обещания запрашиваются
-	if err != nil {
+	requestedUserID, ok := targetUserID(c) // ID пользователя, чьи обещания запрашиваются
+	if !ok {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID пользователя"})
 		return
 	}
 
-	currentUserID, _ := uuid.Parse(c.GetString("user_id")) // ID текущего пользователя
-	isAdmin := c.GetString("role") == "admin"
-
-	// Получаем обещания пользователя
-	promises, err := services.GetPromiseByUserID(requestedUserID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения обещаний"})
+	filter, page, ok := promiseListQuery(c)
+	if !ok {
 		return
 	}
+	filter.OwnerID = &requestedUserID
 
-	// Если запрашивает не владелец и не админ – скрываем приватные обещания
-	if requestedUserID != currentUserID && !isAdmin {
-		var filteredPromises []models.Promise
-		for _, promise := range promises {
-			if !promise.IsPrivate {
-				filteredPromises = append(filteredPromises, promise)
-			}
-		}
-		promises = filteredPromises
-	}
+	// Приватные, скрытые модератором и обещания ограниченного автора видны только тем, кому их можно читать
+	filter.PublicOnly = !authz.Can(middleware.Actor(c), authz.PromiseRead, &models.Promise{UserID: requestedUserID})
 
-	c.JSON(http.StatusOK, promises)
+	respondPromiseList(c, filter, page)
 }
 
-// GetAllPublicPromisesHandler – получение всех публичных обещаний
+// GetAllPublicPromisesHandler получает публичную ленту обещаний
+// @Summary Публичная лента обещаний
+// @Description Страница публичных обещаний без скрытых модерацией и без обещаний приостановленных авторов
+// @Tags promises
+// @Produce json
+// @Param limit query int false "Размер страницы (1–100, по умолчанию 20)"
+// @Param cursor query string false "Курсор следующей страницы (next_cursor)"
+// @Param sort query string false "Сортировка: created_at (по умолчанию) или deadline"
+// @Param order query string false "Порядок: desc (по умолчанию) или asc"
+// @Param status query string false "Статус: pending, in_progress, completed"
+// @Param owner_id query string false "ID автора"
+// @Param deadline_from query string false "Дедлайн не раньше (RFC 3339)"
+// @Param deadline_to query string false "Дедлайн раньше (RFC 3339)"
+// @Param parent_id query string false "ID родительского обещания; none – только основные"
+// @Success 200 {object} pagination.Result[models.Promise]
+// @Failure 400 {object} map[string]string "error: Неверные параметры"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /promises [get]
 func GetAllPublicPromisesHandler(c *gin.Context) {
-	promises, err := services.GetAllPublicPromises()
+	filter, page, ok := promiseListQuery(c)
+	if !ok {
+		return
+	}
+	filter.PublicOnly = true
+
+	respondPromiseList(c, filter, page)
+}
+
+// promiseListQuery – фильтры и страница списка обещаний из query; при ошибке уже ответил 400
+func promiseListQuery(c *gin.Context) (dto.PromiseFilter, pagination.Page, bool) {
+	filter, err := parsePromiseFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return filter, pagination.Page{}, false
+	}
+	page, err := parsePage(c, "created_at", "deadline")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return filter, page, false
+	}
+	return filter, page, true
+}
+
+// respondPromiseList – страница обещаний в конверте {items, next_cursor}
+func respondPromiseList(c *gin.Context, filter dto.PromiseFilter, page pagination.Page) {
+	result, err := services.ListPromises(filter, page)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения обещаний"})
 		return
 	}
-
-	c.JSON(http.StatusOK, promises)
+	c.JSON(http.StatusOK, result)
 }
 
 // UpdatePromiseHandler обновляет обещание (автор или админ)
@@ -165,24 +301,31 @@ func UpdatePromiseHandler(c *gin.Context) {
 		return
 	}
 
-	// Получаем ID пользователя
-	userID, _ := uuid.Parse(c.GetString("user_id"))
 	promiseID := c.Param("id")
-	isAdmin := c.GetString("role") == "admin"
 
-	// Обновляем обещание через сервис
-	err := services.UpdatePromise(userID, promiseID, req, isAdmin)
+	// Обновляем обещание через сервис (права проверяются там)
+	actor := middleware.Actor(c)
+	promise, changes, err := services.UpdatePromise(actor, promiseID, req)
 	if err != nil {
+		if errors.Is(err, services.ErrEmailNotVerified) || errors.Is(err, services.ErrNotAllowedToUpdate) || errors.Is(err, services.ErrTitleLocked) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	// Правка своих обещаний – обычное действие; в журнал аудита попадает только правка чужих
+	if promise.UserID != actor.ID && changes != nil {
+		recordAudit(c, models.AuditLog{Action: models.AuditPromiseUpdate, TargetType: models.AuditTargetPromise, TargetID: &promise.ID, Changes: changes})
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Обещание обновлено"})
 }
 
-// DeletePromiseHandler удаляет обещание (только для админов)
+// DeletePromiseHandler удаляет обещание (promise.delete.any или promise.delete.own)
 // @Summary Удаление обещания
-// @Description Позволяет администратору удалить обещание по ID
+// @Description Удаляет обещание по ID, если роли разрешено удалять любые или свои обещания
 // @Tags admin
 // @Security BearerAuth
 // @Param id path string true "ID обещания"
@@ -191,22 +334,24 @@ func UpdatePromiseHandler(c *gin.Context) {
 // @Failure 400 {object} map[string]string "error: Ошибка при удалении обещания"
 // @Router /admin/promises/{id} [delete]
 func DeletePromiseHandler(c *gin.Context) {
-	// Проверяем, является ли пользователь админом
-	isAdmin := c.GetString("role") == "admin"
-	if !isAdmin {
-		c.JSON(http.StatusForbidden, gin.H{"error": "У вас нет прав на удаление обещания"})
-		return
-	}
-
 	// ID обещания для удаления
 	promiseID := c.Param("id")
 
-	// Вызываем сервис удаления
-	err := services.DeletePromise(promiseID)
+	// Вызываем сервис удаления (права проверяются там)
+	promise, err := services.DeletePromise(middleware.Actor(c), promiseID)
 	if err != nil {
+		if errors.Is(err, services.ErrNotAllowedToDelete) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrPromiseNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	recordAudit(c, models.AuditLog{Action: models.AuditPromiseDelete, TargetType: models.AuditTargetPromise, TargetID: &promise.ID, Changes: services.AuditDiff(promise, nil)})
 
 	c.JSON(http.StatusOK, gin.H{"message": "Обещание удалено"})
 }
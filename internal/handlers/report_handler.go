@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/internal/dto"
+	"github.com/raxaris/ipromise-backend/internal/middleware"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"github.com/raxaris/ipromise-backend/internal/services"
+)
+
+// ReportPromiseHandler принимает жалобу на обещание
+// @Summary Пожаловаться на обещание
+// @Description Жалоба на публичное обещание. Повторная жалоба, пока первая не рассмотрена, не создаётся
+// @Tags reports
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "ID обещания"
+// @Param input body dto.CreateReportRequest true "Причина: spam, abuse, harassment, inappropriate, other"
+// @Success 201 {object} map[string]string "message: Жалоба отправлена"
+// @Success 200 {object} map[string]string "message: Жалоба уже отправлена"
+// @Failure 400 {object} map[string]string "error: Ошибка валидации"
+// @Failure 404 {object} map[string]string "error: Обещание не найдено"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /promises/{id}/report [post]
+func ReportPromiseHandler(c *gin.Context) {
+	promiseID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID обещания"})
+		return
+	}
+
+	var req dto.CreateReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reporterID, _ := uuid.Parse(c.GetString("user_id"))
+
+	created, err := services.ReportPromise(reporterID, promiseID, req)
+	respondReportCreated(c, created, err)
+}
+
+// ReportUserHandler принимает жалобу на профиль пользователя
+// @Summary Пожаловаться на пользователя
+// @Description Жалоба на профиль. Повторная жалоба, пока первая не рассмотрена, не создаётся
+// @Tags reports
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param username path string true "Имя пользователя"
+// @Param input body dto.CreateReportRequest true "Причина: spam, abuse, harassment, inappropriate, other"
+// @Success 201 {object} map[string]string "message: Жалоба отправлена"
+// @Success 200 {object} map[string]string "message: Жалоба уже отправлена"
+// @Failure 400 {object} map[string]string "error: Ошибка валидации"
+// @Failure 404 {object} map[string]string "error: Пользователь не найден"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /users/{username}/report [post]
+func ReportUserHandler(c *gin.Context) {
+	var req dto.CreateReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reporterID, _ := uuid.Parse(c.GetString("user_id"))
+
+	created, err := services.ReportUser(reporterID, c.Param("username"), req)
+	respondReportCreated(c, created, err)
+}
+
+// respondReportCreated – общий ответ на создание жалобы
+func respondReportCreated(c *gin.Context, created bool, err error) {
+	switch {
+	case err == nil && created:
+		c.JSON(http.StatusCreated, gin.H{"message": "Жалоба отправлена"})
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"message": "Жалоба уже отправлена и ждёт рассмотрения"})
+	case errors.Is(err, services.ErrPromiseNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Обещание не найдено"})
+	case errors.Is(err, services.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Пользователь не найден"})
+	case errors.Is(err, services.ErrCannotReportSelf):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка отправки жалобы"})
+	}
+}
+
+// GetReportQueueHandler возвращает очередь жалоб
+// @Summary Очередь жалоб
+// @Description Объекты с открытыми жалобами: число жалоб, причины, время первой и последней. Первыми – ждущие дольше всех
+// @Tags moderation
+// @Security BearerAuth
+// @Produce json
+// @Param target_type query string false "promise или user"
+// @Param limit query int false "Количество записей (до 200)"
+// @Success 200 {array} dto.ReportQueueItem
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /moderation/reports [get]
+func GetReportQueueHandler(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	queue, err := services.GetReportQueue(c.Query("target_type"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения очереди жалоб"})
+		return
+	}
+
+	c.JSON(http.StatusOK, queue)
+}
+
+// GetTargetReportsHandler возвращает открытые жалобы на объект
+// @Summary Жалобы на объект
+// @Description Открытые жалобы на обещание или пользователя с комментариями авторов
+// @Tags moderation
+// @Security BearerAuth
+// @Produce json
+// @Param target_type query string true "promise или user"
+// @Param target_id query string true "ID объекта"
+// @Success 200 {array} models.Report
+// @Failure 400 {object} map[string]string "error: Неверные параметры"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /moderation/reports/target [get]
+func GetTargetReportsHandler(c *gin.Context) {
+	targetID, err := uuid.Parse(c.Query("target_id"))
+	if err != nil || c.Query("target_type") == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Нужны target_type и target_id"})
+		return
+	}
+
+	reports, err := services.GetOpenReports(c.Query("target_type"), targetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения жалоб"})
+		return
+	}
+
+	c.JSON(http.StatusOK, reports)
+}
+
+// ResolveReportsHandler выносит решение по жалобам
+// @Summary Решение по жалобам
+// @Description Закрывает все открытые жалобы на объект: dismissed – нарушения нет, hidden – скрыть обещание, user_suspended – приостановить пользователя (для обещания – автора)
+// @Tags moderation
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body dto.ResolveReportsRequest true "Объект и решение"
+// @Success 200 {object} models.ReportDecision
+// @Failure 400 {object} map[string]string "error: Ошибка валидации или неприменимое решение"
+// @Failure 403 {object} map[string]string "error: Нет прав на это решение"
+// @Failure 404 {object} map[string]string "error: Объект не найден"
+// @Failure 409 {object} map[string]string "error: Открытых жалоб нет"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /moderation/reports/resolve [post]
+func ResolveReportsHandler(c *gin.Context) {
+	var req dto.ResolveReportsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	decision, err := services.ResolveReports(middleware.Actor(c), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidResolution):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrNotAllowedToResolve), errors.Is(err, services.ErrCannotSuspendStaff):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrPromiseNotFound), errors.Is(err, services.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrNoOpenReports):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка рассмотрения жалоб"})
+		}
+		return
+	}
+	recordAudit(c, models.AuditLog{Action: models.AuditReportResolve, TargetType: req.TargetType, TargetID: &req.TargetID,
+		Changes: models.AuditChanges{"resolution": {To: req.Resolution}, "decision_id": {To: decision.ID}}})
+
+	c.JSON(http.StatusOK, decision)
+}
+
+// GetReportDecisionsHandler возвращает историю решений по жалобам
+// @Summary История решений
+// @Description Решения модераторов по жалобам, новые первыми
+// @Tags moderation
+// @Security BearerAuth
+// @Produce json
+// @Param target_type query string false "promise или user"
+// @Param target_id query string false "ID объекта"
+// @Param limit query int false "Количество записей (до 200)"
+// @Success 200 {array} models.ReportDecision
+// @Failure 400 {object} map[string]string "error: Неверные параметры"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /moderation/reports/decisions [get]
+func GetReportDecisionsHandler(c *gin.Context) {
+	var targetID *uuid.UUID
+	if raw := c.Query("target_id"); raw != "" {
+		parsed, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат target_id"})
+			return
+		}
+		targetID = &parsed
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	decisions, err := services.GetReportDecisions(c.Query("target_type"), targetID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения истории решений"})
+		return
+	}
+
+	c.JSON(http.StatusOK, decisions)
+}
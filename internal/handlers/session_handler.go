@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"github.com/raxaris/ipromise-backend/internal/services"
+)
+
+// GetSessionsHandler возвращает активные сессии пользователя
+// @Summary Список активных сессий
+// @Description Возвращает сессии текущего пользователя: время входа, последнего использования, User-Agent и IP
+// @Tags sessions
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} dto.SessionResponse
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /profile/sessions [get]
+func GetSessionsHandler(c *gin.Context) {
+	userID, _ := uuid.Parse(c.GetString("user_id"))
+
+	sessions, err := services.GetUserSessions(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения сессий"})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+// RevokeSessionHandler завершает одну сессию
+// @Summary Завершение сессии
+// @Description Отзывает все Refresh-токены выбранной сессии
+// @Tags sessions
+// @Security BearerAuth
+// @Param id path string true "ID сессии"
+// @Success 200 {object} map[string]string "message: Сессия завершена"
+// @Failure 400 {object} map[string]string "error: Неверный формат ID сессии"
+// @Failure 404 {object} map[string]string "error: Сессия не найдена"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /profile/sessions/{id} [delete]
+func RevokeSessionHandler(c *gin.Context) {
+	userID, _ := uuid.Parse(c.GetString("user_id"))
+
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID сессии"})
+		return
+	}
+
+	err = services.RevokeSession(userID, sessionID)
+	if err != nil {
+		if errors.Is(err, services.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Сессия не найдена"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка завершения сессии"})
+		return
+	}
+	recordAudit(c, models.AuditLog{Action: models.AuditSessionRevoke, TargetType: models.AuditTargetSession, TargetID: &sessionID})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Сессия завершена"})
+}
+
+// RevokeAllSessionsHandler завершает все сессии пользователя
+// @Summary Выход со всех устройств
+// @Description Отзывает все Refresh-токены текущего пользователя
+// @Tags sessions
+// @Security BearerAuth
+// @Success 200 {object} map[string]string "message: Все сессии завершены"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /profile/sessions [delete]
+func RevokeAllSessionsHandler(c *gin.Context) {
+	userID, _ := uuid.Parse(c.GetString("user_id"))
+
+	if err := services.RevokeAllSessions(userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка завершения сессий"})
+		return
+	}
+	recordAudit(c, models.AuditLog{Action: models.AuditSessionsRevokeAll, TargetType: models.AuditTargetUser, TargetID: &userID})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Все сессии завершены"})
+}
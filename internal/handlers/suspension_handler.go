@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/internal/dto"
+	"github.com/raxaris/ipromise-backend/internal/middleware"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"github.com/raxaris/ipromise-backend/internal/services"
+)
+
+// SuspendUserHandler приостанавливает аккаунт на срок
+// @Summary Приостановка аккаунта
+// @Description Запрещает вход до указанного момента, завершает все сессии и скрывает публичные обещания пользователя. Бессрочный бан не заменяется
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "ID пользователя"
+// @Param input body dto.SuspendUserRequest true "Причина и срок"
+// @Success 200 {object} map[string]string "message: Аккаунт приостановлен"
+// @Failure 400 {object} map[string]string "error: Ошибка валидации"
+// @Failure 403 {object} map[string]string "error: Нельзя приостановить аккаунт сотрудника"
+// @Failure 404 {object} map[string]string "error: Пользователь не найден"
+// @Failure 409 {object} map[string]string "error: Аккаунт уже заблокирован бессрочно"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /admin/users/{id}/suspend [post]
+func SuspendUserHandler(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID"})
+		return
+	}
+
+	var req dto.SuspendUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := services.SuspendUser(middleware.Actor(c).ID, userID, req.Reason, req.Until); err != nil {
+		respondSuspensionError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditLog{Action: models.AuditUserSuspend, TargetType: models.AuditTargetUser, TargetID: &userID,
+		Changes: models.AuditChanges{"suspended_until": {To: req.Until}, "suspended_reason": {To: req.Reason}}})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Аккаунт приостановлен"})
+}
+
+// BanUserHandler бессрочно блокирует аккаунт
+// @Summary Бан аккаунта
+// @Description Бессрочно запрещает вход, завершает все сессии и скрывает публичные обещания пользователя
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "ID пользователя"
+// @Param input body dto.BanUserRequest true "Причина"
+// @Success 200 {object} map[string]string "message: Аккаунт заблокирован"
+// @Failure 400 {object} map[string]string "error: Ошибка валидации"
+// @Failure 403 {object} map[string]string "error: Нельзя заблокировать аккаунт сотрудника"
+// @Failure 404 {object} map[string]string "error: Пользователь не найден"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /admin/users/{id}/ban [post]
+func BanUserHandler(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID"})
+		return
+	}
+
+	var req dto.BanUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := services.BanUser(middleware.Actor(c).ID, userID, req.Reason); err != nil {
+		respondSuspensionError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditLog{Action: models.AuditUserBan, TargetType: models.AuditTargetUser, TargetID: &userID,
+		Changes: models.AuditChanges{"suspended_reason": {To: req.Reason}}})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Аккаунт заблокирован"})
+}
+
+// LiftRestrictionHandler снимает приостановку или бан
+// @Summary Снятие приостановки или бана
+// @Description Возвращает пользователю доступ к аккаунту. Снять бессрочный бан может только тот, у кого есть право user.ban
+// @Tags admin
+// @Security BearerAuth
+// @Param id path string true "ID пользователя"
+// @Success 200 {object} map[string]string "message: Ограничение снято"
+// @Failure 400 {object} map[string]string "error: Неверный формат ID"
+// @Failure 403 {object} map[string]string "error: Нет прав снимать бан"
+// @Failure 404 {object} map[string]string "error: Пользователь не найден"
+// @Failure 409 {object} map[string]string "error: Аккаунт не ограничен"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /admin/users/{id}/restriction [delete]
+func LiftRestrictionHandler(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID"})
+		return
+	}
+
+	if err := services.LiftRestriction(middleware.Actor(c), userID); err != nil {
+		respondSuspensionError(c, err)
+		return
+	}
+	recordAudit(c, models.AuditLog{Action: models.AuditUserRestrictionLift, TargetType: models.AuditTargetUser, TargetID: &userID})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Ограничение снято"})
+}
+
+// respondSuspensionError – ответ на ошибку приостановки или бана
+func respondSuspensionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidSuspension):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrCannotSuspendStaff), errors.Is(err, services.ErrNotAllowedToBan):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Пользователь не найден"})
+	case errors.Is(err, services.ErrUserNotRestricted), errors.Is(err, services.ErrUserAlreadyBanned):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка изменения ограничений аккаунта"})
+	}
+}
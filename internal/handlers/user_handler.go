@@ -1,13 +1,16 @@
 package handlers
 
 import (
+	"errors"
 	"github.com/raxaris/ipromise-backend/config"
 	"github.com/raxaris/ipromise-backend/internal/models"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/internal/authz"
 	"github.com/raxaris/ipromise-backend/internal/dto"
+	"github.com/raxaris/ipromise-backend/internal/middleware"
 	"github.com/raxaris/ipromise-backend/internal/services"
 )
 
@@ -55,14 +58,26 @@ func GetPublicUserHandler(c *gin.Context) {
 
 // GetAllUsersHandler получает список всех пользователей (только для админов)
 // @Summary Получение всех пользователей
-// @Description Возвращает список всех зарегистрированных пользователей
+// @Description Страница зарегистрированных пользователей по дате регистрации
 // @Tags admin
 // @Security BearerAuth
-// @Success 200 {array} models.User
+// @Produce json
+// @Param limit query int false "Размер страницы (1–100, по умолчанию 20)"
+// @Param cursor query string false "Курсор следующей страницы (next_cursor)"
+// @Param order query string false "Порядок: desc (по умолчанию) или asc"
+// @Param role query string false "Роль"
+// @Success 200 {object} pagination.Result[models.User]
+// @Failure 400 {object} map[string]string "error: Неверные параметры"
 // @Failure 500 {object} map[string]string "error: Ошибка сервера"
 // @Router /admin/users [get]
 func GetAllUsersHandler(c *gin.Context) {
-	users, err := services.GetAllUsers()
+	page, err := parsePage(c, "created_at")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	users, err := services.ListUsers(c.Query("role"), page)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения пользователей"})
 		return
@@ -122,15 +137,17 @@ func GetUserByUsernameHandler(c *gin.Context) {
 
 // UpdateUserHandler обновляет профиль пользователя
 // @Summary Обновление профиля пользователя
-// @Description Позволяет изменить username (доступно только самому пользователю)
+// @Description Позволяет изменить username своего профиля; через /admin/users/{id} – профиль другого пользователя и роль (user.update.any, user.role.set)
 // @Tags users
 // @Security BearerAuth
+// @Param id path string false "ID пользователя (только /admin/users/{id})"
 // @Param input body dto.UpdateUserRequest true "Данные для обновления"
 // @Success 200 {object} map[string]string "message: Данные пользователя обновлены"
 // @Failure 400 {object} map[string]string "error: Ошибка валидации"
 // @Failure 403 {object} map[string]string "error: Нет прав на редактирование"
 // @Failure 500 {object} map[string]string "error: Ошибка сервера"
 // @Router /profile [put]
+// @Router /admin/users/{id} [put]
 func UpdateUserHandler(c *gin.Context) {
 	var req dto.UpdateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -138,42 +155,115 @@ func UpdateUserHandler(c *gin.Context) {
 		return
 	}
 
-	userID, _ := uuid.Parse(c.GetString("user_id"))
+	userID, ok := targetUserID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID"})
+		return
+	}
 
-	err := services.UpdateUser(userID, userID, &req, false)
+	changes, err := services.UpdateUser(middleware.Actor(c), userID, &req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		switch {
+		case errors.Is(err, services.ErrNotAllowedToEdit):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Пользователь не найден"})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
+	if changes != nil {
+		action := models.AuditUserUpdate
+		if _, ok := changes["role"]; ok {
+			action = models.AuditUserRoleChange
+		}
+		recordAudit(c, models.AuditLog{Action: action, TargetType: models.AuditTargetUser, TargetID: &userID, Changes: changes})
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Данные пользователя обновлены"})
 }
 
 // DeleteUserHandler удаляет аккаунт пользователя
 // @Summary Удаление аккаунта
-// @Description Удаляет аккаунт текущего пользователя
+// @Description Удаляет аккаунт текущего пользователя; через /admin/users/{id} – любой аккаунт (user.delete.any)
 // @Tags users
 // @Security BearerAuth
+// @Param id path string false "ID пользователя (только /admin/users/{id})"
 // @Success 200 {object} map[string]string "message: Аккаунт удалён"
 // @Failure 404 {object} map[string]string "error: Пользователь не найден"
 // @Failure 500 {object} map[string]string "error: Ошибка удаления"
 // @Router /profile [delete]
+// @Router /admin/users/{id} [delete]
 func DeleteUserHandler(c *gin.Context) {
-	userID, _ := uuid.Parse(c.GetString("user_id"))
+	userID, ok := targetUserID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID"})
+		return
+	}
 
 	// Проверяем, существует ли пользователь
-	_, err := services.GetUserByID(userID)
+	user, err := services.GetUserByID(userID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Пользователь не найден"})
 		return
 	}
 
+	if !authz.Can(middleware.Actor(c), authz.UserDelete, user) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Нет прав на удаление аккаунта"})
+		return
+	}
+
 	// Удаляем пользователя
 	err = services.DeleteUser(userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка удаления пользователя"})
 		return
 	}
+	recordAudit(c, models.AuditLog{Action: models.AuditUserDelete, TargetType: models.AuditTargetUser, TargetID: &userID, Changes: services.AuditDiff(user, nil)})
 
 	c.JSON(http.StatusOK, gin.H{"message": "Аккаунт удалён"})
 }
+
+// UnlockUserHandler снимает блокировку входа
+// @Summary Разблокировка входа
+// @Description Сбрасывает счётчик неудачных входов и снимает временную блокировку аккаунта
+// @Tags admin
+// @Security BearerAuth
+// @Param id path string true "ID пользователя"
+// @Success 200 {object} map[string]string "message: Аккаунт разблокирован"
+// @Failure 400 {object} map[string]string "error: Неверный формат ID"
+// @Failure 404 {object} map[string]string "error: Пользователь не найден"
+// @Failure 500 {object} map[string]string "error: Ошибка сервера"
+// @Router /admin/users/{id}/unlock [post]
+func UnlockUserHandler(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID"})
+		return
+	}
+
+	err = services.UnlockUser(userID)
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Пользователь не найден"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка разблокировки"})
+		return
+	}
+	recordAudit(c, models.AuditLog{Action: models.AuditUserUnlock, TargetType: models.AuditTargetUser, TargetID: &userID})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Аккаунт разблокирован"})
+}
+
+// targetUserID – пользователь из пути (/admin/users/:id) или, если его нет, текущий пользователь
+func targetUserID(c *gin.Context) (uuid.UUID, bool) {
+	if id := c.Param("id"); id != "" {
+		userID, err := uuid.Parse(id)
+		return userID, err == nil
+	}
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	return userID, err == nil
+}
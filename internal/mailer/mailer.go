@@ -0,0 +1,38 @@
+package mailer
+
+import (
+	"log"
+
+	"github.com/raxaris/ipromise-backend/config"
+)
+
+// Message – письмо в виде простого текста
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer – отправка писем пользователям
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// FromConfig – создаёт Mailer по MAIL_DRIVER: smtp или log (по умолчанию)
+func FromConfig() Mailer {
+	switch config.MailDriver {
+	case "smtp":
+		return &SMTPMailer{
+			Host:     config.SMTPHost,
+			Port:     config.SMTPPort,
+			Username: config.SMTPUsername,
+			Password: config.SMTPPassword,
+			From:     config.MailFrom,
+		}
+	case "log", "":
+		return &LogMailer{Path: config.MailLogFile}
+	default:
+		log.Fatal("❌ Неизвестный MAIL_DRIVER: " + config.MailDriver)
+		return nil
+	}
+}
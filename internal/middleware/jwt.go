@@ -1,16 +1,18 @@
 package middleware
 
 import (
-	"github.com/google/uuid"
+	"errors"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/raxaris/ipromise-backend/internal/services"
 )
 
-// AuthMiddleware – Middleware для проверки Access-токена
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware – Middleware для проверки Access-токена.
+// Персональные токены принимаются только на маршрутах, для которых заданы scopes, и должны содержать их все.
+func AuthMiddleware(scopes ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Извлекаем токен из заголовка Authorization: Bearer <token>
 		authHeader := c.GetHeader("Authorization")
@@ -35,6 +37,12 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// Персональный токен для скриптов и интеграций
+		if strings.HasPrefix(tokenString, services.PersonalTokenPrefix) {
+			authenticatePersonalToken(c, tokenString, scopes)
+			return
+		}
+
 		// Валидация токена
 		claims, err := services.ValidateAccessToken(tokenString)
 		if err != nil {
@@ -43,34 +51,147 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Извлекаем user_id
-		userIDStr, ok := claims["user_id"].(string)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Ошибка авторизации (user_id)"})
+		// Проверяем, не отозваны ли токены пользователя (смена роли, пароля, блокировка, удаление)
+		current, err := services.IsAccessTokenCurrent(claims)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка проверки токена"})
+			c.Abort()
+			return
+		}
+		if !current {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Токен отозван"})
 			c.Abort()
 			return
 		}
 
-		// Конвертируем в uuid.UUID
-		userID, err := uuid.Parse(userIDStr)
+		// Извлекаем user_id из `sub`
+		userID, err := claims.UserID()
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Неверный формат ID пользователя"})
 			c.Abort()
 			return
 		}
 
-		// Извлекаем роль пользователя
-		role, ok := claims["role"].(string)
-		if !ok {
+		role := claims.Role
+		if role == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Ошибка авторизации (role)"})
 			c.Abort()
 			return
 		}
 
-		// Передаем user_id и role в контекст Gin
-		c.Set("user_id", userID)
+		if !accountActive(c, services.CheckUserActive(userID)) {
+			return
+		}
+
+		// Передаем user_id и role в контекст Gin (строкой – handlers читают его через c.GetString)
+		c.Set("user_id", userID.String())
 		c.Set("role", role)
+		c.Set("amr", claims.AMR)
+
+		// Администратор работает от имени пользователя: каждый запрос попадает в журнал имперсонации
+		if claims.Act != nil {
+			impersonatorID, err := services.CheckImpersonation(claims)
+			if errors.Is(err, services.ErrImpersonationRevoked) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Токен отозван"})
+				c.Abort()
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка проверки токена"})
+				c.Abort()
+				return
+			}
+
+			c.Set("impersonator_id", impersonatorID.String())
+			c.Set("impersonation_token_id", claims.ID)
+			c.Header("X-Impersonated-By", impersonatorID.String())
+			c.Next()
+			services.RecordImpersonatedRequest(claims, c.Request.Method, c.Request.URL.RequestURI(), c.Writer.Status(), c.ClientIP())
+			return
+		}
 
 		c.Next() // Продолжаем выполнение запроса
 	}
 }
+
+// OptionalAuthMiddleware – для публичных маршрутов: без заголовка Authorization запрос проходит анонимно,
+// с заголовком токен проверяется так же, как в AuthMiddleware
+func OptionalAuthMiddleware(scopes ...string) gin.HandlerFunc {
+	auth := AuthMiddleware(scopes...)
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		auth(c)
+	}
+}
+
+// DenyImpersonation – закрывает маршрут для токенов имперсонации (смена учётных данных, сессии, 2FA, токены)
+func DenyImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("impersonator_id") != "" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Недоступно при входе под пользователем"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// authenticatePersonalToken – проверка персонального токена и его scope
+func authenticatePersonalToken(c *gin.Context, tokenString string, scopes []string) {
+	if len(scopes) == 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Персональный токен не даёт доступа к этому ресурсу"})
+		c.Abort()
+		return
+	}
+
+	token, user, err := services.AuthenticatePersonalToken(tokenString)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Недействительный токен"})
+		c.Abort()
+		return
+	}
+
+	if !accountActive(c, services.CheckAccountActive(user)) {
+		return
+	}
+
+	granted := token.ScopeList()
+	for _, scope := range scopes {
+		if !slices.Contains(granted, scope) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "У токена нет scope " + scope})
+			c.Abort()
+			return
+		}
+	}
+
+	c.Set("user_id", user.ID.String())
+	c.Set("role", user.Role)
+	c.Set("scopes", granted)
+
+	c.Next()
+}
+
+// accountActive – прерывает запрос с 403, если аккаунт приостановлен или заблокирован
+func accountActive(c *gin.Context, err error) bool {
+	if err == nil {
+		return true
+	}
+
+	var restricted *services.AccountRestrictedError
+	if !errors.As(err, &restricted) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка проверки аккаунта"})
+		c.Abort()
+		return false
+	}
+
+	body := gin.H{"error": restricted.Error()}
+	if restricted.Until != nil {
+		body["suspended_until"] = restricted.Until
+	}
+	c.JSON(http.StatusForbidden, body)
+	c.Abort()
+	return false
+}
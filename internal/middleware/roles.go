@@ -2,24 +2,31 @@ package middleware
 
 import (
 	"net/http"
+	"slices"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/internal/authz"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"github.com/raxaris/ipromise-backend/internal/services"
 )
 
-// AdminMiddleware – проверяет, является ли пользователь админом
-func AdminMiddleware() gin.HandlerFunc {
+// RequirePermission – пропускает запрос, если у пользователя есть все перечисленные разрешения
+func RequirePermission(permissions ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		role, exists := c.Get("role")
-		if !exists {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Доступ запрещен"})
-			c.Abort()
-			return
-		}
-
-		// Приводим `role` к строке
-		roleStr, ok := role.(string)
-		if !ok || roleStr != "admin" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Доступ запрещен"})
+		actor := Actor(c)
+		for _, permission := range permissions {
+			if authz.Can(actor, permission, nil) {
+				continue
+			}
+
+			// Разрешение есть у роли, но вход выполнен без 2FA
+			full := authz.Actor{ID: actor.ID, Role: c.GetString("role")}
+			if full.Role != actor.Role && authz.Can(full, permission, nil) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Для этого раздела войдите с двухфакторной аутентификацией"})
+			} else {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Доступ запрещен"})
+			}
 			c.Abort()
 			return
 		}
@@ -28,24 +35,23 @@ func AdminMiddleware() gin.HandlerFunc {
 	}
 }
 
-// ModeratorMiddleware – проверяет, является ли пользователь модератором или админом
-func ModeratorMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		role, exists := c.Get("role")
-		if !exists {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Доступ запрещен"})
-			c.Abort()
-			return
-		}
-
-		// Приводим `role` к строке
-		roleStr, ok := role.(string)
-		if !ok || (roleStr != "moderator" && roleStr != "admin") {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Доступ запрещен"})
-			c.Abort()
-			return
-		}
+// Actor – пользователь запроса для проверок authz.Can.
+// Роль, требующая 2FA, без входа через 2FA даёт только права обычного пользователя.
+func Actor(c *gin.Context) authz.Actor {
+	userID, _ := uuid.Parse(c.GetString("user_id"))
+	role := c.GetString("role")
+	if !mfaSatisfied(c, role) {
+		role = models.RoleUser
+	}
+	return authz.Actor{ID: userID, Role: role}
+}
 
-		c.Next()
+// mfaSatisfied – для ролей из MFA_REQUIRED_ROLES токен должен быть получен через 2FA
+func mfaSatisfied(c *gin.Context, role string) bool {
+	if !services.IsMFARequired(role) {
+		return true
 	}
+	amr, _ := c.Get("amr")
+	methods, _ := amr.([]string)
+	return slices.Contains(methods, services.AuthMethodOTP)
 }
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Действия, попадающие в журнал аудита
+const (
+	AuditAuthLogin           = "auth.login"
+	AuditAuthRefresh         = "auth.refresh"
+	AuditAuthPasswordReset   = "auth.password.reset"
+	AuditPasswordChange      = "user.password.change"
+	AuditEmailChangeRequest  = "user.email.change_request"
+	AuditMFAEnable           = "user.mfa.enable"
+	AuditMFADisable          = "user.mfa.disable"
+	AuditMFARecoveryCodes    = "user.mfa.recovery_codes"
+	AuditTokenCreate         = "user.token.create"
+	AuditTokenRevoke         = "user.token.revoke"
+	AuditSessionRevoke       = "user.session.revoke"
+	AuditSessionsRevokeAll   = "user.sessions.revoke_all"
+	AuditUserUpdate          = "user.update"
+	AuditUserRoleChange      = "user.role.change"
+	AuditUserDelete          = "user.delete"
+	AuditUserUnlock          = "user.unlock"
+	AuditUserSuspend         = "user.suspend"
+	AuditUserBan             = "user.ban"
+	AuditUserRestrictionLift = "user.restriction.lift"
+	AuditUserImpersonate     = "user.impersonate"
+	AuditImpersonationEnd    = "user.impersonate.end"
+	AuditPromiseUpdate       = "promise.update"
+	AuditPromiseDelete       = "promise.delete"
+	AuditPromiseHide         = "promise.hide"
+	AuditPromiseUnhide       = "promise.unhide"
+	AuditPromiseTitleEdit    = "promise.title.edit"
+	AuditReportResolve       = "report.resolve"
+)
+
+// Типы объектов в журнале аудита
+const (
+	AuditTargetUser    = "user"
+	AuditTargetPromise = "promise"
+	AuditTargetSession = "session"
+	AuditTargetToken   = "token"
+)
+
+// AuditChange – значение поля до и после действия
+type AuditChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// AuditChanges – изменённые поля объекта; хранится в jsonb
+type AuditChanges map[string]AuditChange
+
+// Value – сериализация для записи в БД
+func (c AuditChanges) Value() (driver.Value, error) {
+	if c == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(c)
+	return string(data), err
+}
+
+// Scan – чтение из БД
+func (c *AuditChanges) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	default:
+		return fmt.Errorf("неподдерживаемый тип для AuditChanges: %T", value)
+	}
+}
+
+// AuditLog – запись журнала аудита. Таблица только дополняется: UPDATE и DELETE запрещены триггером,
+// удалять старые записи может лишь очистка по сроку хранения (AUDIT_RETENTION).
+type AuditLog struct {
+	ID             uuid.UUID    `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	ActorID        *uuid.UUID   `gorm:"type:uuid;index" json:"actor_id,omitempty"`
+	ImpersonatorID *uuid.UUID   `gorm:"type:uuid;index" json:"impersonator_id,omitempty"` // Администратор, вошедший под ActorID
+	Action         string       `gorm:"type:varchar(64);not null;index" json:"action"`
+	TargetType     string       `gorm:"type:varchar(16);index:idx_audit_logs_target" json:"target_type,omitempty"`
+	TargetID       *uuid.UUID   `gorm:"type:uuid;index:idx_audit_logs_target" json:"target_id,omitempty"`
+	Changes        AuditChanges `gorm:"type:jsonb" json:"changes,omitempty" swaggertype:"object"`
+	IP             string       `gorm:"type:varchar(45)" json:"ip,omitempty"`
+	RequestID      string       `gorm:"type:varchar(64);index" json:"request_id,omitempty"`
+	CreatedAt      time.Time    `gorm:"index" json:"created_at"`
+}
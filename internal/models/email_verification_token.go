@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// EmailVerificationToken – одноразовый токен подтверждения адреса (хранится только хеш)
+type EmailVerificationToken struct {
+	gorm.Model `swaggerignore:"true"`
+	ID         uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index"`
+	Email      string     `gorm:"not null"` // Адрес, который подтверждается этим токеном
+	TokenHash  string     `gorm:"type:char(64);uniqueIndex;not null"`
+	ExpiresAt  time.Time  `gorm:"not null"`
+	UsedAt     *time.Time // Заполняется при использовании или замене новым запросом
+}
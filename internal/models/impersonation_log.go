@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// События имперсонации
+const (
+	ImpersonationStarted = "started" // Администратор получил токен
+	ImpersonationRequest = "request" // Запрос, выполненный по токену имперсонации
+	ImpersonationEnded   = "ended"   // Токен отозван до истечения срока
+)
+
+// ImpersonationLog – запись о входе администратора под пользователем и о каждом его действии от имени пользователя
+type ImpersonationLog struct {
+	ID             uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	TokenID        string    `gorm:"type:varchar(64);index" json:"token_id"` // jti токена имперсонации
+	ImpersonatorID uuid.UUID `gorm:"type:uuid;not null;index" json:"impersonator_id"`
+	UserID         uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	Event          string    `gorm:"type:varchar(16);not null" json:"event"`
+	Method         string    `gorm:"type:varchar(10)" json:"method,omitempty"`
+	Path           string    `gorm:"type:varchar(500)" json:"path,omitempty"`
+	Status         int       `json:"status,omitempty"`
+	IP             string    `gorm:"type:varchar(45)" json:"ip"`
+	CreatedAt      time.Time `gorm:"index" json:"created_at"`
+}
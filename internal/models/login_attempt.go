@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Причины неудачного входа
+const (
+	LoginFailureUnknownUser  = "unknown_user"
+	LoginFailureBadPassword  = "bad_password"
+	LoginFailureBadMFACode   = "bad_mfa_code"
+	LoginFailureBadMagicLink = "bad_magic_link"
+	LoginFailureLocked       = "locked"
+	LoginFailureIPThrottled  = "ip_throttled"
+)
+
+// LoginAttempt – запись о попытке входа, для ограничения перебора и разбора атак
+type LoginAttempt struct {
+	ID        uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID    *uuid.UUID `gorm:"type:uuid;index" json:"user_id,omitempty"`
+	Email     string     `gorm:"index" json:"email"`
+	IP        string     `gorm:"type:varchar(45);index:idx_login_attempts_ip_created" json:"ip"`
+	Success   bool       `gorm:"not null" json:"success"`
+	Reason    string     `gorm:"type:varchar(32)" json:"reason,omitempty"`
+	CreatedAt time.Time  `gorm:"index:idx_login_attempts_ip_created" json:"created_at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MagicLinkToken – выданная ссылка входа без пароля. Сама ссылка – подписанный JWT,
+// здесь хранится только его jti, чтобы ссылку можно было использовать один раз.
+type MagicLinkToken struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey"` // jti токена из ссылки
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index"`
+	IP        string     `gorm:"type:varchar(45);index"` // Откуда запрошена ссылка
+	ExpiresAt time.Time  `gorm:"not null"`
+	UsedAt    *time.Time // Заполняется при входе или замене новой ссылкой
+	CreatedAt time.Time  `gorm:"index"`
+}
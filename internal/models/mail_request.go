@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Виды запросов писем
+const (
+	MailRequestPasswordReset = "password_reset"
+	MailRequestMagicLink     = "magic_link"
+)
+
+// MailRequest – запрос письма по email, указанному без входа. Записывается и для незарегистрированных адресов,
+// чтобы лимит на IP не зависел от существования аккаунта.
+type MailRequest struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Kind      string    `gorm:"type:varchar(32);not null;index:idx_mail_requests_kind_ip_created" json:"kind"`
+	IP        string    `gorm:"type:varchar(45);not null;index:idx_mail_requests_kind_ip_created" json:"ip"`
+	CreatedAt time.Time `gorm:"index:idx_mail_requests_kind_ip_created" json:"created_at"`
+}
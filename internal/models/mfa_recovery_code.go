@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MFARecoveryCode – одноразовый код восстановления для входа без приложения-аутентификатора
+type MFARecoveryCode struct {
+	gorm.Model `swaggerignore:"true"`
+	ID         uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null;index"`
+	CodeHash   string    `gorm:"type:char(64);not null;index"`
+	UsedAt     *time.Time
+}
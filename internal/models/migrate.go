@@ -1,14 +1,125 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"log"
+
+	"gorm.io/gorm"
+)
 
 func MigrateDB(db *gorm.DB) {
+	if err := dropPlainRefreshTokens(db); err != nil {
+		panic("❌ Ошибка миграции refresh_tokens: " + err.Error())
+	}
+	// Колонки email_verified_at ещё нет – аккаунты созданы до подтверждения email
+	backfillVerified := db.Migrator().HasTable(&User{}) && !db.Migrator().HasColumn(&User{}, "email_verified_at")
+
 	err := db.AutoMigrate(
 		&User{},
 		&RefreshToken{},
 		&Promise{},
+		&PasswordResetToken{},
+		&MailRequest{},
+		&EmailVerificationToken{},
+		&MFARecoveryCode{},
+		&LoginAttempt{},
+		&PersonalAccessToken{},
+		&UserIdentity{},
+		&OIDCAuthState{},
+		&MagicLinkToken{},
+		&Report{},
+		&ReportDecision{},
+		&ImpersonationLog{},
+		&AuditLog{},
 	)
 	if err != nil {
 		panic("❌ Ошибка миграции: " + err.Error())
 	}
+
+	if backfillVerified {
+		if err := backfillEmailVerified(db); err != nil {
+			panic("❌ Ошибка миграции email_verified_at: " + err.Error())
+		}
+	}
+	if err := protectAuditLog(db); err != nil {
+		panic("❌ Ошибка миграции audit_logs: " + err.Error())
+	}
+	if err := createListIndexes(db); err != nil {
+		panic("❌ Ошибка создания индексов списков: " + err.Error())
+	}
+	if err := createSearchVector(db); err != nil {
+		panic("❌ Ошибка миграции полнотекстового поиска: " + err.Error())
+	}
+}
+
+// createListIndexes – индексы для постраничных списков: сортировка по полю и id совпадает с условием курсора.
+// created_at приходит из gorm.Model, поэтому составные индексы задаются здесь, а не тегами.
+func createListIndexes(db *gorm.DB) error {
+	statements := []string{
+		"CREATE INDEX IF NOT EXISTS idx_promises_created_at_id ON promises (created_at, id)",
+		"CREATE INDEX IF NOT EXISTS idx_promises_deadline_id ON promises (deadline, id)",
+		"CREATE INDEX IF NOT EXISTS idx_promises_user_created_at_id ON promises (user_id, created_at, id)",
+		// Публичная лента
+		"CREATE INDEX IF NOT EXISTS idx_promises_public_feed ON promises (created_at, id) WHERE is_private = false AND hidden_at IS NULL AND deleted_at IS NULL",
+		"CREATE INDEX IF NOT EXISTS idx_users_created_at_id ON users (created_at, id)",
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillEmailVerified – однократно считает подтверждёнными email аккаунтов, созданных до появления подтверждения,
+// чтобы REQUIRE_VERIFIED_EMAIL_FOR_PUBLIC не закрыл публикацию всем существующим пользователям
+func backfillEmailVerified(db *gorm.DB) error {
+	res := db.Exec("UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL")
+	if res.Error != nil {
+		return res.Error
+	}
+	log.Printf("📧 Email существующих аккаунтов считается подтверждённым: %d", res.RowsAffected)
+	return nil
+}
+
+// AuditPurgeSetting – параметр транзакции, при котором триггер разрешает удалять записи аудита (очистка по сроку)
+const AuditPurgeSetting = "ipromise.audit_purge"
+
+// protectAuditLog – триггер, запрещающий изменять и удалять записи журнала аудита
+func protectAuditLog(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`
+CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP = 'DELETE' AND current_setting('` + AuditPurgeSetting + `', true) = 'on' THEN
+		RETURN OLD;
+	END IF;
+	RAISE EXCEPTION 'audit_logs: журнал аудита только дополняется';
+END;
+$$ LANGUAGE plpgsql`).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Exec("DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs").Error; err != nil {
+			return err
+		}
+		return tx.Exec("CREATE TRIGGER audit_logs_append_only BEFORE UPDATE OR DELETE ON audit_logs FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only()").Error
+	})
+}
+
+// dropPlainRefreshTokens – однократно удаляет Refresh-токены, хранившиеся в открытом виде.
+// Старые токены – подписанные JWT, а не непрозрачные значения, поэтому сессии завершаются и пользователи входят заново.
+func dropPlainRefreshTokens(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&RefreshToken{}) || !migrator.HasColumn(&RefreshToken{}, "token") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Exec("DELETE FROM refresh_tokens")
+		if res.Error != nil {
+			return res.Error
+		}
+		log.Printf("🔑 Refresh-токены переведены на хранение хешей, завершено сессий: %d", res.RowsAffected)
+		return tx.Migrator().DropColumn(&RefreshToken{}, "token")
+	})
 }
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PasswordResetToken – одноразовый токен сброса пароля (хранится только хеш)
+type PasswordResetToken struct {
+	gorm.Model `swaggerignore:"true"`
+	ID         uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index"`
+	IP         string     `gorm:"type:varchar(45);index"` // Откуда запрошен сброс
+	TokenHash  string     `gorm:"type:char(64);uniqueIndex;not null"`
+	ExpiresAt  time.Time  `gorm:"not null"`
+	UsedAt     *time.Time // Заполняется при использовании или замене новым запросом
+}
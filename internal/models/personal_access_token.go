@@ -0,0 +1,36 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PersonalAccessToken – токен пользователя для скриптов и интеграций (хранится только хеш)
+type PersonalAccessToken struct {
+	gorm.Model `swaggerignore:"true"`
+	ID         uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index"`
+	Name       string     `gorm:"type:varchar(100);not null"`
+	TokenHash  string     `gorm:"type:char(64);uniqueIndex;not null"`
+	Prefix     string     `gorm:"type:varchar(16);not null"` // Начало токена, чтобы пользователь узнал его в списке
+	Scopes     string     `gorm:"type:text;not null"`        // Через запятую
+	ExpiresAt  *time.Time // NULL – бессрочный
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+// ScopeList – список scope токена
+func (t *PersonalAccessToken) ScopeList() []string {
+	if t.Scopes == "" {
+		return nil
+	}
+	return strings.Split(t.Scopes, ",")
+}
+
+// IsActive – не отозван и не истёк на момент now
+func (t *PersonalAccessToken) IsActive(now time.Time) bool {
+	return t.RevokedAt == nil && (t.ExpiresAt == nil || now.Before(*t.ExpiresAt))
+}
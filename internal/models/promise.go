@@ -17,6 +17,24 @@ type Promise struct {
 	Deadline    time.Time  `gorm:"not null"`
 	Status      string     `gorm:"type:varchar(20);default:pending"`
 	IsPrivate   bool       `gorm:"default:false" json:"is_private"`
+
+	// Модерация: скрытое обещание не показывается в публичных списках
+	HiddenAt     *time.Time `gorm:"index" json:"hidden_at,omitempty"`
+	HiddenByID   *uuid.UUID `gorm:"type:uuid" json:"-"` // Модератор не раскрывается автору и читателям
+	HiddenReason string     `gorm:"type:varchar(500)" json:"hidden_reason,omitempty"`
+
+	// Заголовок исправлен модератором – автор больше не может его менять
+	TitleModeratedAt *time.Time `json:"title_moderated_at,omitempty"`
+}
+
+// IsHidden – скрыто ли обещание модератором
+func (p *Promise) IsHidden() bool {
+	return p.HiddenAt != nil
+}
+
+// OwnerID – автор обещания (для проверок доступа)
+func (p *Promise) OwnerID() uuid.UUID {
+	return p.UserID
 }
 
 func (p *Promise) BeforeCreate(tx *gorm.DB) (err error) {
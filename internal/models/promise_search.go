@@ -0,0 +1,53 @@
+package models
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// SearchConfigs – конфигурации полнотекстового поиска PostgreSQL, по которым индексируются обещания.
+// Первая используется для подсветки фрагментов. При изменении списка колонка search_vector пересоздаётся.
+var SearchConfigs = []string{"russian", "english"}
+
+// searchVectorExpression – выражение генерируемой колонки: заголовок с весом A, описание с весом B в каждой конфигурации
+func searchVectorExpression() string {
+	parts := make([]string, 0, len(SearchConfigs)*2)
+	for _, column := range []struct{ name, weight string }{{"title", "A"}, {"description", "B"}} {
+		for _, name := range SearchConfigs {
+			parts = append(parts, "setweight(to_tsvector('"+name+"', coalesce("+column.name+", '')), '"+column.weight+"')")
+		}
+	}
+	return strings.Join(parts, " || ")
+}
+
+// createSearchVector – генерируемая колонка promises.search_vector и GIN-индекс по ней.
+// В комментарии колонки хранится список конфигураций, чтобы пересоздать её, если SearchConfigs изменился.
+func createSearchVector(db *gorm.DB) error {
+	signature := strings.Join(SearchConfigs, ",")
+
+	var columns []struct{ Comment *string }
+	err := db.Raw(`SELECT col_description(attrelid, attnum) AS comment FROM pg_attribute
+		WHERE attrelid = 'promises'::regclass AND attname = 'search_vector' AND NOT attisdropped`).Scan(&columns).Error
+	if err != nil {
+		return err
+	}
+	if len(columns) == 1 && columns[0].Comment != nil && *columns[0].Comment == signature {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		statements := []string{
+			"ALTER TABLE promises DROP COLUMN IF EXISTS search_vector",
+			"ALTER TABLE promises ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (" + searchVectorExpression() + ") STORED",
+			"COMMENT ON COLUMN promises.search_vector IS '" + signature + "'",
+			"CREATE INDEX idx_promises_search_vector ON promises USING GIN (search_vector)",
+		}
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
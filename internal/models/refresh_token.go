@@ -2,15 +2,33 @@ package models
 
 import (
 	"github.com/google/uuid"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
 )
 
 type RefreshToken struct {
-	gorm.Model `swaggerignore:"true"`
-	ID         uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
-	UserID     uuid.UUID `gorm:"type:uuid;not null;index"`
-	Token      string    `gorm:"unique;not null"`
-	ExpiresAt  time.Time `gorm:"not null"`
+	gorm.Model       `swaggerignore:"true"`
+	ID               uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	UserID           uuid.UUID  `gorm:"type:uuid;not null;index"`
+	FamilyID         uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();not null;index"` // Цепочка токенов одной сессии
+	TokenHash        string     `gorm:"type:char(64);uniqueIndex;not null"`                 // HMAC-SHA256 от токена, сам токен не хранится
+	ExpiresAt        time.Time  `gorm:"not null"`
+	UsedAt           *time.Time // Время погашения при ротации
+	RevokedAt        *time.Time // Время отзыва (повторное использование, выход)
+	ReplacedByID     *uuid.UUID `gorm:"type:uuid"`     // Токен, выданный взамен этого
+	SessionStartedAt time.Time  `gorm:"default:now()"` // Время входа, переносится при ротации
+	LastUsedAt       time.Time  `gorm:"default:now()"`
+	UserAgent        string     `gorm:"type:text"`
+	IP               string     `gorm:"type:varchar(45)"`
+	AuthMethods      string     `gorm:"type:varchar(64)"` // Способы входа через запятую (amr), переносятся при ротации
+}
+
+// AMR – способы входа, которыми была открыта сессия
+func (t *RefreshToken) AMR() []string {
+	if t.AuthMethods == "" {
+		return nil
+	}
+	return strings.Split(t.AuthMethods, ",")
 }
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Типы объектов жалоб
+const (
+	ReportTargetPromise = "promise"
+	ReportTargetUser    = "user"
+)
+
+// Причины жалоб
+const (
+	ReportReasonSpam          = "spam"
+	ReportReasonAbuse         = "abuse"
+	ReportReasonHarassment    = "harassment"
+	ReportReasonInappropriate = "inappropriate"
+	ReportReasonOther         = "other"
+)
+
+// Статусы жалоб
+const (
+	ReportStatusOpen     = "open"
+	ReportStatusResolved = "resolved"
+)
+
+// Решения модератора
+const (
+	ReportResolutionDismissed     = "dismissed"
+	ReportResolutionHidden        = "hidden"
+	ReportResolutionUserSuspended = "user_suspended"
+)
+
+// Report – жалоба пользователя на обещание или профиль.
+// Пока жалоба открыта, повторная жалоба того же пользователя на тот же объект не создаётся.
+type Report struct {
+	ID         uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	ReporterID uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_reports_open_per_reporter,where:status = 'open'" json:"reporter_id"`
+	TargetType string     `gorm:"type:varchar(16);not null;uniqueIndex:idx_reports_open_per_reporter;index:idx_reports_target" json:"target_type"`
+	TargetID   uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_reports_open_per_reporter;index:idx_reports_target" json:"target_id"`
+	Reason     string     `gorm:"type:varchar(32);not null" json:"reason"`
+	Comment    string     `gorm:"type:varchar(1000)" json:"comment,omitempty"`
+	Status     string     `gorm:"type:varchar(16);not null;default:'open';index" json:"status"`
+	DecisionID *uuid.UUID `gorm:"type:uuid;index" json:"decision_id,omitempty"` // Решение, которым закрыта жалоба
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// ReportDecision – решение модератора по объекту; закрывает все открытые жалобы на него
+type ReportDecision struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	TargetType  string    `gorm:"type:varchar(16);not null;index:idx_report_decisions_target" json:"target_type"`
+	TargetID    uuid.UUID `gorm:"type:uuid;not null;index:idx_report_decisions_target" json:"target_id"`
+	ModeratorID uuid.UUID `gorm:"type:uuid;not null;index" json:"moderator_id"`
+	Resolution  string    `gorm:"type:varchar(32);not null" json:"resolution"`
+	Note        string    `gorm:"type:varchar(1000)" json:"note,omitempty"`
+	ReportCount int       `gorm:"not null" json:"report_count"` // Сколько жалоб закрыто решением
+	CreatedAt   time.Time `gorm:"index" json:"created_at"`
+}
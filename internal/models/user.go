@@ -1,8 +1,10 @@
 package models
 
 import (
+	"time"
+
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/raxaris/ipromise-backend/internal/password"
 	"gorm.io/gorm"
 )
 
@@ -14,24 +16,71 @@ const (
 )
 
 type User struct {
-	gorm.Model `swaggerignore:"true"`
-	ID         uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	Username   string    `gorm:"unique;not null"`
-	Email      string    `gorm:"unique;not null"`
-	Password   string    `gorm:"not null" json:"-"`
-	Role       string    `gorm:"type:varchar(15);default:'user'" json:"role"`
+	gorm.Model      `swaggerignore:"true"`
+	ID              uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Username        string     `gorm:"unique;not null"`
+	Email           string     `gorm:"unique;not null"`
+	Password        string     `gorm:"not null" json:"-"`
+	Role            string     `gorm:"type:varchar(15);default:'user'" json:"role"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	TokenVersion    int        `gorm:"not null;default:0" json:"-"` // Access-токены со старой версией считаются отозванными
+	TOTPSecret      string     `gorm:"type:varchar(64)" json:"-"`   // Секрет TOTP (до подтверждения – ожидающий)
+	TOTPEnabledAt   *time.Time `json:"totp_enabled_at"`
+	TOTPLastStep    int64      `gorm:"not null;default:0" json:"-"` // Последний принятый шаг TOTP, защита от повтора кода
+	FailedLogins    int        `gorm:"not null;default:0" json:"-"` // Неудачные входы подряд
+	LockedUntil     *time.Time `json:"locked_until,omitempty"`      // Вход временно заблокирован до этого времени
+	SuspendedAt     *time.Time `json:"suspended_at,omitempty"`      // Аккаунт ограничен модерацией или администратором
+	SuspendedUntil  *time.Time `json:"suspended_until,omitempty"`   // Конец приостановки; пусто при заданном SuspendedAt – бессрочный бан
+	SuspendedByID   *uuid.UUID `gorm:"type:uuid" json:"suspended_by_id,omitempty"`
+	SuspendedReason string     `gorm:"type:varchar(500)" json:"suspended_reason,omitempty"`
+}
+
+// OwnerID – владелец профиля – сам пользователь (для проверок доступа)
+func (u *User) OwnerID() uuid.UUID {
+	return u.ID
+}
+
+// IsLocked – заблокирован ли вход в аккаунт на момент now
+func (u *User) IsLocked(now time.Time) bool {
+	return u.LockedUntil != nil && now.Before(*u.LockedUntil)
+}
+
+// IsBanned – заблокирован ли аккаунт бессрочно
+func (u *User) IsBanned() bool {
+	return u.SuspendedAt != nil && u.SuspendedUntil == nil
 }
 
+// IsRestricted – действует ли на момент now приостановка или бан
+func (u *User) IsRestricted(now time.Time) bool {
+	return u.SuspendedAt != nil && (u.SuspendedUntil == nil || now.Before(*u.SuspendedUntil))
+}
+
+// IsTOTPEnabled – включена ли двухфакторная аутентификация
+func (u *User) IsTOTPEnabled() bool {
+	return u.TOTPEnabledAt != nil
+}
+
+// IsEmailVerified – подтверждён ли текущий email
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// HashPassword – заменяет открытый пароль в Password хешем по текущей политике (PASSWORD_HASH_*)
 func (u *User) HashPassword() error {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
+	hash, err := password.Hash(u.Password, password.Current())
 	if err != nil {
 		return err
 	}
-	u.Password = string(bytes)
+	u.Password = hash
 	return nil
 }
 
-func (u *User) CheckPassword(password string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
-	return err == nil
+// CheckPassword – проверяет пароль; понимает и argon2id, и старые bcrypt-хеши
+func (u *User) CheckPassword(plain string) bool {
+	return password.Verify(plain, u.Password)
+}
+
+// NeedsRehash – хеш пароля создан по устаревшей политике и его стоит пересчитать при входе
+func (u *User) NeedsRehash() bool {
+	return password.NeedsRehash(u.Password, password.Current())
 }
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserIdentity – привязка аккаунта к пользователю внешнего OIDC-провайдера
+type UserIdentity struct {
+	gorm.Model `swaggerignore:"true"`
+	ID         uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	Provider   string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_user_identities_provider_subject" json:"provider"`
+	Subject    string    `gorm:"not null;uniqueIndex:idx_user_identities_provider_subject" json:"subject"` // `sub` из ID-токена
+	Email      string    `json:"email"`                                                                    // Email у провайдера на момент привязки
+}
+
+// OIDCAuthState – незавершённый вход через OIDC: state, nonce и PKCE verifier
+type OIDCAuthState struct {
+	ID           uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	StateHash    string    `gorm:"type:char(64);uniqueIndex;not null"`
+	Provider     string    `gorm:"type:varchar(50);not null"`
+	Nonce        string    `gorm:"not null"`
+	CodeVerifier string    `gorm:"not null"`
+	ExpiresAt    time.Time `gorm:"not null;index"`
+	CreatedAt    time.Time
+}
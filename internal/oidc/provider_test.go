@@ -0,0 +1,199 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID = "ipromise-test"
+	testKeyID    = "test-key"
+)
+
+// testIssuer – OIDC-провайдер на httptest.Server: discovery, JWKS и token endpoint
+type testIssuer struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	mu     sync.Mutex
+	claims jwt.MapClaims // Claims следующего выданного ID-токена
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ti := &testIssuer{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{
+			"issuer":                 ti.URL,
+			"authorization_endpoint": ti.URL + "/authorize",
+			"token_endpoint":         ti.URL + "/token",
+			"jwks_uri":               ti.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": testKeyID,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" ||
+			r.PostForm.Get("code") == "" || r.PostForm.Get("code_verifier") == "" {
+			http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+			return
+		}
+
+		ti.mu.Lock()
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, ti.claims)
+		ti.mu.Unlock()
+		token.Header["kid"] = testKeyID
+		signed, err := token.SignedString(key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, map[string]string{"id_token": signed})
+	})
+
+	ti.Server = httptest.NewServer(mux)
+	t.Cleanup(ti.Close)
+	return ti
+}
+
+// issue – задаёт claims следующего ID-токена: корректные для nonce, с правками из override
+func (ti *testIssuer) issue(nonce string, override jwt.MapClaims) {
+	claims := jwt.MapClaims{
+		"iss":            ti.URL,
+		"aud":            testClientID,
+		"sub":            "subject-1",
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Minute).Unix(),
+		"nonce":          nonce,
+		"email":          "user@example.com",
+		"email_verified": true,
+	}
+	for name, value := range override {
+		claims[name] = value
+	}
+
+	ti.mu.Lock()
+	ti.claims = claims
+	ti.mu.Unlock()
+}
+
+func (ti *testIssuer) provider() *Provider {
+	return NewProvider("mock", ti.URL+"/", testClientID, "secret", "http://localhost/auth/oidc/mock/callback", nil)
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	ti := newTestIssuer(t)
+
+	authURL, err := ti.provider().AuthCodeURL(context.Background(), "state-1", "nonce-1", "verifier-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := parsed.Query()
+	want := map[string]string{
+		"client_id":             testClientID,
+		"state":                 "state-1",
+		"nonce":                 "nonce-1",
+		"code_challenge":        CodeChallenge("verifier-1"),
+		"code_challenge_method": "S256",
+	}
+	for name, value := range want {
+		if got := query.Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+}
+
+func TestExchange(t *testing.T) {
+	ti := newTestIssuer(t)
+	ti.issue("nonce-1", nil)
+
+	claims, err := ti.provider().Exchange(context.Background(), "code-1", "verifier-1", "nonce-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "subject-1" || claims.Email != "user@example.com" || !claims.EmailVerified {
+		t.Errorf("claims = %+v", claims)
+	}
+}
+
+func TestExchangeRejectsInvalidIDToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		nonce    string
+		override jwt.MapClaims
+	}{
+		{"nonce mismatch", "other-nonce", nil},
+		{"missing nonce", "nonce-1", jwt.MapClaims{"nonce": ""}},
+		{"wrong audience", "nonce-1", jwt.MapClaims{"aud": "another-client"}},
+		{"wrong issuer", "nonce-1", jwt.MapClaims{"iss": "https://attacker.example"}},
+		{"expired", "nonce-1", jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()}},
+		{"missing subject", "nonce-1", jwt.MapClaims{"sub": ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ti := newTestIssuer(t)
+			ti.issue(tt.nonce, tt.override)
+
+			_, err := ti.provider().Exchange(context.Background(), "code-1", "verifier-1", "nonce-1")
+			if !errors.Is(err, ErrInvalidToken) {
+				t.Fatalf("err = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestDiscoveryRejectsForeignIssuer(t *testing.T) {
+	// Документ описывает другого издателя – токены такого провайдера не принимаются
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{
+			"issuer":                 "https://attacker.example",
+			"authorization_endpoint": "https://attacker.example/authorize",
+			"token_endpoint":         "https://attacker.example/token",
+			"jwks_uri":               "https://attacker.example/jwks",
+		})
+	}))
+	defer server.Close()
+
+	provider := NewProvider("mock", server.URL, testClientID, "", "http://localhost/callback", nil)
+	if _, err := provider.AuthCodeURL(context.Background(), "state", "nonce", "verifier"); !errors.Is(err, ErrDiscovery) {
+		t.Fatalf("err = %v, want ErrDiscovery", err)
+	}
+}
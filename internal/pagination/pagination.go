@@ -0,0 +1,88 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Размер страницы по умолчанию и максимальный
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Ошибки
+var (
+	ErrInvalidCursor = errors.New("недействительный курсор")
+	ErrInvalidSort   = errors.New("недопустимое поле сортировки")
+	ErrInvalidLimit  = errors.New("limit должен быть числом от 1 до 100")
+)
+
+// Cursor – позиция после последней выданной записи: значение поля сортировки и ID (для одинаковых значений).
+// Для сортировки по релевантности поиска значение хранится в Rank, для остальных – в Value.
+type Cursor struct {
+	Sort  string    `json:"s"`
+	Desc  bool      `json:"d"`
+	Value time.Time `json:"v"`
+	Rank  float64   `json:"r,omitempty"`
+	ID    uuid.UUID `json:"i"`
+}
+
+// Page – параметры страницы: сортировка по Sort (с ID как вторым ключом), размер и курсор
+type Page struct {
+	Limit int
+	Sort  string
+	Desc  bool
+	After *Cursor // nil – первая страница
+}
+
+// Result – страница записей и курсор следующей (nil – записей больше нет)
+type Result[T any] struct {
+	Items      []T     `json:"items"`
+	NextCursor *string `json:"next_cursor"`
+}
+
+// Encode – непрозрачное представление курсора для клиента
+func Encode(cursor Cursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Decode – разбирает курсор и проверяет, что он выдан для той же сортировки
+func Decode(raw, sort string, desc bool) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.Sort != sort || cursor.Desc != desc {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// Finish – обрезает лишнюю запись (репозиторий читает Limit+1) и строит курсор следующей страницы.
+// key возвращает позицию записи (Value или Rank и ID); сортировку Finish заполняет сам.
+func Finish[T any](items []T, page Page, key func(T) Cursor) Result[T] {
+	result := Result[T]{Items: items}
+	if result.Items == nil {
+		result.Items = []T{}
+	}
+	if len(items) <= page.Limit {
+		return result
+	}
+
+	result.Items = items[:page.Limit]
+	cursor := key(result.Items[page.Limit-1])
+	cursor.Sort, cursor.Desc = page.Sort, page.Desc
+	next := Encode(cursor)
+	result.NextCursor = &next
+	return result
+}
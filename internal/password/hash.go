@@ -0,0 +1,133 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Алгоритмы хеширования
+const (
+	Argon2id = "argon2id"
+	Bcrypt   = "bcrypt"
+)
+
+const (
+	saltLength = 16
+	keyLength  = 32
+)
+
+// Ошибки
+var (
+	ErrUnknownAlgorithm = errors.New("неизвестный алгоритм хеширования пароля")
+	ErrMalformedHash    = errors.New("повреждённый хеш пароля")
+)
+
+// Params – текущая политика хеширования. Хеши с другими параметрами пересчитываются при входе.
+type Params struct {
+	Algorithm     string
+	Argon2Memory  uint32 // КиБ
+	Argon2Time    uint32 // Число проходов
+	Argon2Threads uint8
+	BcryptCost    int
+}
+
+var (
+	encoding = base64.RawStdEncoding
+
+	// Политика по умолчанию – рекомендации OWASP для argon2id
+	current = Params{Algorithm: Argon2id, Argon2Memory: 64 * 1024, Argon2Time: 3, Argon2Threads: 2, BcryptCost: bcrypt.DefaultCost}
+)
+
+// SetCurrent – задаёт политику для новых хешей
+func SetCurrent(p Params) {
+	current = p
+}
+
+// Current – политика для новых хешей
+func Current() Params {
+	return current
+}
+
+// Hash – хеширует пароль по политике p. Argon2id сохраняется в формате PHC:
+// $argon2id$v=19$m=65536,t=3,p=2$<соль>$<хеш>; bcrypt – в своём стандартном формате $2a$...
+func Hash(password string, p Params) (string, error) {
+	switch p.Algorithm {
+	case Argon2id:
+		salt := make([]byte, saltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		key := argon2.IDKey([]byte(password), salt, p.Argon2Time, p.Argon2Memory, p.Argon2Threads, keyLength)
+		return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s", Argon2id, argon2.Version,
+			p.Argon2Memory, p.Argon2Time, p.Argon2Threads, encoding.EncodeToString(salt), encoding.EncodeToString(key)), nil
+	case Bcrypt:
+		bytes, err := bcrypt.GenerateFromPassword([]byte(password), p.BcryptCost)
+		return string(bytes), err
+	default:
+		return "", ErrUnknownAlgorithm
+	}
+}
+
+// Verify – проверяет пароль по хешу любого поддерживаемого формата
+func Verify(password, encoded string) bool {
+	if strings.HasPrefix(encoded, "$"+Argon2id+"$") {
+		h, err := parseArgon2(encoded)
+		if err != nil {
+			return false
+		}
+		key := argon2.IDKey([]byte(password), h.salt, h.time, h.memory, h.threads, uint32(len(h.key)))
+		return subtle.ConstantTimeCompare(key, h.key) == 1
+	}
+	return bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password)) == nil
+}
+
+// NeedsRehash – отличается ли алгоритм или параметры хеша от политики p
+func NeedsRehash(encoded string, p Params) bool {
+	if strings.HasPrefix(encoded, "$"+Argon2id+"$") {
+		h, err := parseArgon2(encoded)
+		return err != nil || p.Algorithm != Argon2id || h.version != argon2.Version ||
+			h.memory != p.Argon2Memory || h.time != p.Argon2Time || h.threads != p.Argon2Threads
+	}
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || p.Algorithm != Bcrypt || cost != p.BcryptCost
+}
+
+type argon2Hash struct {
+	version int
+	memory  uint32
+	time    uint32
+	threads uint8
+	salt    []byte
+	key     []byte
+}
+
+func parseArgon2(encoded string) (*argon2Hash, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != Argon2id {
+		return nil, ErrMalformedHash
+	}
+
+	var h argon2Hash
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &h.version); err != nil {
+		return nil, ErrMalformedHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &h.memory, &h.time, &h.threads); err != nil {
+		return nil, ErrMalformedHash
+	}
+
+	var err error
+	if h.salt, err = encoding.DecodeString(parts[4]); err != nil {
+		return nil, ErrMalformedHash
+	}
+	if h.key, err = encoding.DecodeString(parts[5]); err != nil || len(h.key) == 0 {
+		return nil, ErrMalformedHash
+	}
+	return &h, nil
+}
@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/raxaris/ipromise-backend/config"
+	"github.com/raxaris/ipromise-backend/internal/dto"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// CreateAuditLog – добавляет запись в журнал аудита
+func CreateAuditLog(entry *models.AuditLog) error {
+	return config.DB.Create(entry).Error
+}
+
+// GetAuditLogs – записи журнала аудита по фильтрам, новые первыми
+func GetAuditLogs(filter dto.AuditLogFilter) ([]models.AuditLog, error) {
+	query := config.DB.Model(&models.AuditLog{})
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ? OR impersonator_id = ?", *filter.ActorID, *filter.ActorID)
+	}
+	if filter.Action != "" {
+		if filter.Action[len(filter.Action)-1] == '.' {
+			query = query.Where("action LIKE ?", filter.Action+"%")
+		} else {
+			query = query.Where("action = ?", filter.Action)
+		}
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != nil {
+		query = query.Where("target_id = ?", *filter.TargetID)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if filter.IP != "" {
+		query = query.Where("ip = ?", filter.IP)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	var entries []models.AuditLog
+	err := query.Order("created_at DESC").Limit(filter.Limit).Find(&entries).Error
+	return entries, err
+}
+
+// PurgeAuditLogs – удаляет записи старше before (единственное разрешённое триггером удаление)
+func PurgeAuditLogs(before time.Time) (int64, error) {
+	var deleted int64
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT set_config(?, 'on', true)", models.AuditPurgeSetting).Error; err != nil {
+			return err
+		}
+		res := tx.Where("created_at < ?", before).Delete(&models.AuditLog{})
+		deleted = res.RowsAffected
+		return res.Error
+	})
+	return deleted, err
+}
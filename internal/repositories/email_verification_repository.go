@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/config"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// CreateEmailVerificationToken – сохраняет новый токен, гася прежние неиспользованные
+func CreateEmailVerificationToken(token *models.EmailVerificationToken) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.EmailVerificationToken{}).
+			Where("user_id = ? AND used_at IS NULL", token.UserID).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+// CountEmailVerificationsByUser – сколько писем подтверждения отправлено пользователю начиная с since
+func CountEmailVerificationsByUser(userID uuid.UUID, since time.Time) (int64, error) {
+	var count int64
+	err := config.DB.Model(&models.EmailVerificationToken{}).
+		Where("user_id = ? AND created_at >= ?", userID, since).
+		Count(&count).Error
+	return count, err
+}
+
+// GetOldestEmailVerificationByUser – самое раннее письмо подтверждения в окне (для Retry-After)
+func GetOldestEmailVerificationByUser(userID uuid.UUID, since time.Time) (*models.EmailVerificationToken, error) {
+	var token models.EmailVerificationToken
+	err := config.DB.Where("user_id = ? AND created_at >= ?", userID, since).Order("created_at").First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// GetEmailVerificationTokenByHash – получает токен подтверждения по хешу
+func GetEmailVerificationTokenByHash(hash string) (*models.EmailVerificationToken, error) {
+	var token models.EmailVerificationToken
+	if err := config.DB.First(&token, "token_hash = ?", hash).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ConsumeEmailVerificationToken – помечает токен использованным и подтверждает адрес из токена.
+// Возвращает false, если токен уже использован параллельным запросом.
+func ConsumeEmailVerificationToken(token *models.EmailVerificationToken) (bool, error) {
+	consumed := false
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		res := tx.Model(&models.EmailVerificationToken{}).
+			Where("id = ? AND used_at IS NULL", token.ID).
+			Update("used_at", now)
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		consumed = true
+		return tx.Model(&models.User{}).Where("id = ?", token.UserID).
+			Updates(map[string]interface{}{"email": token.Email, "email_verified_at": now}).Error
+	})
+	return consumed && err == nil, err
+}
@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/config"
+	"github.com/raxaris/ipromise-backend/internal/models"
+)
+
+// CreateImpersonationLog – записывает событие имперсонации
+func CreateImpersonationLog(entry *models.ImpersonationLog) error {
+	return config.DB.Create(entry).Error
+}
+
+// GetImpersonationStart – запись о выдаче токена имперсонации по jti
+func GetImpersonationStart(tokenID string) (*models.ImpersonationLog, error) {
+	var entry models.ImpersonationLog
+	err := config.DB.First(&entry, "token_id = ? AND event = ?", tokenID, models.ImpersonationStarted).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// IsImpersonationEnded – отозван ли токен имперсонации
+func IsImpersonationEnded(tokenID string) (bool, error) {
+	var count int64
+	err := config.DB.Model(&models.ImpersonationLog{}).
+		Where("token_id = ? AND event = ?", tokenID, models.ImpersonationEnded).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetImpersonationLogs – журнал имперсонации с фильтрами, новые записи первыми
+func GetImpersonationLogs(impersonatorID, userID *uuid.UUID, tokenID string, limit int) ([]models.ImpersonationLog, error) {
+	query := config.DB.Model(&models.ImpersonationLog{})
+	if impersonatorID != nil {
+		query = query.Where("impersonator_id = ?", *impersonatorID)
+	}
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+	if tokenID != "" {
+		query = query.Where("token_id = ?", tokenID)
+	}
+
+	var entries []models.ImpersonationLog
+	err := query.Order("created_at DESC").Limit(limit).Find(&entries).Error
+	return entries, err
+}
@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/config"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateLoginAttempt – записывает попытку входа
+func CreateLoginAttempt(attempt *models.LoginAttempt) error {
+	return config.DB.Create(attempt).Error
+}
+
+// PurgeLoginAttempts – удаляет попытки входа старше before, возвращает число удалённых
+func PurgeLoginAttempts(before time.Time) (int64, error) {
+	res := config.DB.Where("created_at < ?", before).Delete(&models.LoginAttempt{})
+	return res.RowsAffected, res.Error
+}
+
+// CountFailedLoginsByIP – число неудачных входов с IP начиная с since.
+// Отклонённые из-за самого ограничения попытки не считаются, иначе окно никогда не закроется.
+func CountFailedLoginsByIP(ip string, since time.Time) (int64, error) {
+	var count int64
+	err := config.DB.Model(&models.LoginAttempt{}).
+		Where("ip = ? AND success = ? AND reason <> ? AND created_at >= ?", ip, false, models.LoginFailureIPThrottled, since).
+		Count(&count).Error
+	return count, err
+}
+
+// GetOldestFailedLoginByIP – самая ранняя неудачная попытка в окне (для Retry-After)
+func GetOldestFailedLoginByIP(ip string, since time.Time) (*models.LoginAttempt, error) {
+	var attempt models.LoginAttempt
+	err := config.DB.Where("ip = ? AND success = ? AND reason <> ? AND created_at >= ?", ip, false, models.LoginFailureIPThrottled, since).
+		Order("created_at").First(&attempt).Error
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// GetLoginAttempts – последние попытки входа с фильтрами (пустые значения не фильтруют)
+func GetLoginAttempts(email, ip string, userID *uuid.UUID, onlyFailed bool, limit int) ([]models.LoginAttempt, error) {
+	query := config.DB.Model(&models.LoginAttempt{})
+	if email != "" {
+		query = query.Where("email = ?", email)
+	}
+	if ip != "" {
+		query = query.Where("ip = ?", ip)
+	}
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+	if onlyFailed {
+		query = query.Where("success = ?", false)
+	}
+
+	var attempts []models.LoginAttempt
+	err := query.Order("created_at DESC").Limit(limit).Find(&attempts).Error
+	return attempts, err
+}
+
+// IncrementFailedLogins – увеличивает счётчик неудачных входов и возвращает новое значение
+func IncrementFailedLogins(userID uuid.UUID) (int, error) {
+	var user models.User
+	err := config.DB.Model(&user).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "failed_logins"}}}).
+		Where("id = ?", userID).
+		Update("failed_logins", gorm.Expr("failed_logins + 1")).Error
+	return user.FailedLogins, err
+}
+
+// LockUser – блокирует вход до указанного времени
+func LockUser(userID uuid.UUID, until time.Time) error {
+	return config.DB.Model(&models.User{}).Where("id = ?", userID).Update("locked_until", until).Error
+}
+
+// ResetFailedLogins – сбрасывает счётчик и снимает блокировку входа
+func ResetFailedLogins(userID uuid.UUID) error {
+	return config.DB.Model(&models.User{}).Where("id = ?", userID).
+		Updates(map[string]interface{}{"failed_logins": 0, "locked_until": nil}).Error
+}
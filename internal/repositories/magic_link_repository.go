@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/config"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// CreateMagicLinkToken – сохраняет новую ссылку входа, гася прежние неиспользованные
+func CreateMagicLinkToken(token *models.MagicLinkToken) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.MagicLinkToken{}).
+			Where("user_id = ? AND used_at IS NULL", token.UserID).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+// CountMagicLinksByUser – сколько ссылок запрошено для пользователя начиная с since
+func CountMagicLinksByUser(userID uuid.UUID, since time.Time) (int64, error) {
+	var count int64
+	err := config.DB.Model(&models.MagicLinkToken{}).
+		Where("user_id = ? AND created_at >= ?", userID, since).
+		Count(&count).Error
+	return count, err
+}
+
+// ConsumeMagicLinkToken – помечает ссылку использованной и подтверждает email (письмо дошло до владельца).
+// Возвращает false, если ссылка уже использована, заменена или истекла.
+func ConsumeMagicLinkToken(id, userID uuid.UUID) (bool, error) {
+	consumed := false
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		res := tx.Model(&models.MagicLinkToken{}).
+			Where("id = ? AND user_id = ? AND used_at IS NULL AND expires_at > ?", id, userID, now).
+			Update("used_at", now)
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		consumed = true
+		return tx.Model(&models.User{}).
+			Where("id = ? AND email_verified_at IS NULL", userID).
+			Update("email_verified_at", now).Error
+	})
+	return consumed && err == nil, err
+}
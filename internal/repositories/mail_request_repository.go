@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/raxaris/ipromise-backend/config"
+	"github.com/raxaris/ipromise-backend/internal/models"
+)
+
+// CreateMailRequest – записывает запрос письма
+func CreateMailRequest(request *models.MailRequest) error {
+	return config.DB.Create(request).Error
+}
+
+// CountMailRequestsByIP – сколько писем вида kind запрошено с IP начиная с since
+func CountMailRequestsByIP(kind, ip string, since time.Time) (int64, error) {
+	var count int64
+	err := config.DB.Model(&models.MailRequest{}).
+		Where("kind = ? AND ip = ? AND created_at >= ?", kind, ip, since).
+		Count(&count).Error
+	return count, err
+}
+
+// PurgeMailRequests – удаляет запросы вида kind старше before: для лимита они больше не нужны
+func PurgeMailRequests(kind string, before time.Time) error {
+	return config.DB.Where("kind = ? AND created_at < ?", kind, before).Delete(&models.MailRequest{}).Error
+}
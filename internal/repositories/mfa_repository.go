@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/config"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// SetPendingTOTPSecret – сохраняет секрет, ещё не подтверждённый кодом
+func SetPendingTOTPSecret(userID uuid.UUID, secret string) error {
+	return config.DB.Model(&models.User{}).
+		Where("id = ? AND totp_enabled_at IS NULL", userID).
+		Update("totp_secret", secret).Error
+}
+
+// EnableTOTP – включает 2FA и заменяет коды восстановления
+func EnableTOTP(userID uuid.UUID, step int64, codeHashes []string) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).
+			Updates(map[string]interface{}{"totp_enabled_at": time.Now(), "totp_last_step": step}).Error; err != nil {
+			return err
+		}
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+// DisableTOTP – выключает 2FA и удаляет коды восстановления
+func DisableTOTP(userID uuid.UUID) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).
+			Updates(map[string]interface{}{"totp_secret": "", "totp_enabled_at": nil, "totp_last_step": 0}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error
+	})
+}
+
+// AdvanceTOTPStep – запоминает принятый шаг TOTP; false, если этот код уже использовали
+func AdvanceTOTPStep(userID uuid.UUID, step int64) (bool, error) {
+	res := config.DB.Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", userID, step).
+		Update("totp_last_step", step)
+	return res.RowsAffected > 0, res.Error
+}
+
+// ReplaceRecoveryCodes – заменяет все коды восстановления пользователя
+func ReplaceRecoveryCodes(userID uuid.UUID, codeHashes []string) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+// ConsumeRecoveryCode – гасит код восстановления; false, если кода нет или он использован
+func ConsumeRecoveryCode(userID uuid.UUID, codeHash string) (bool, error) {
+	res := config.DB.Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	return res.RowsAffected > 0, res.Error
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID uuid.UUID, codeHashes []string) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+		return err
+	}
+	codes := make([]models.MFARecoveryCode, 0, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes = append(codes, models.MFARecoveryCode{ID: uuid.New(), UserID: userID, CodeHash: hash})
+	}
+	return tx.Create(&codes).Error
+}
@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"github.com/raxaris/ipromise-backend/internal/pagination"
+	"gorm.io/gorm"
+)
+
+// paginate – сортировка по column и id с условием курсора (keyset). Читает Limit+1 записей,
+// чтобы pagination.Finish понял, есть ли следующая страница.
+func paginate(query *gorm.DB, page pagination.Page, column string) *gorm.DB {
+	var after interface{}
+	if page.After != nil {
+		after = page.After.Value
+	}
+	return keyset(query, page, column, after)
+}
+
+// keyset – то же для произвольного выражения сортировки; after – его значение из курсора
+func keyset(query *gorm.DB, page pagination.Page, expression string, after interface{}) *gorm.DB {
+	direction, compare := "ASC", ">"
+	if page.Desc {
+		direction, compare = "DESC", "<"
+	}
+	if page.After != nil {
+		query = query.Where("("+expression+", id) "+compare+" (?, ?)", after, page.After.ID)
+	}
+	return query.Order(expression + " " + direction).Order("id " + direction).Limit(page.Limit + 1)
+}
@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/config"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// CreatePasswordResetToken – сохраняет новый токен, гася прежние неиспользованные
+func CreatePasswordResetToken(token *models.PasswordResetToken) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", token.UserID).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+// CountPasswordResetsByUser – сколько ссылок сброса запрошено для пользователя начиная с since
+func CountPasswordResetsByUser(userID uuid.UUID, since time.Time) (int64, error) {
+	var count int64
+	err := config.DB.Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND created_at >= ?", userID, since).
+		Count(&count).Error
+	return count, err
+}
+
+// GetPasswordResetTokenByHash – получает токен сброса по хешу
+func GetPasswordResetTokenByHash(hash string) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	if err := config.DB.First(&token, "token_hash = ?", hash).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ConsumePasswordResetToken – помечает токен использованным и меняет пароль пользователя.
+// Возвращает false, если токен уже использован параллельным запросом.
+func ConsumePasswordResetToken(tokenID, userID uuid.UUID, passwordHash string) (bool, error) {
+	consumed := false
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL", tokenID).
+			Update("used_at", time.Now())
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		consumed = true
+		return tx.Model(&models.User{}).Where("id = ?", userID).Update("password", passwordHash).Error
+	})
+	return consumed && err == nil, err
+}
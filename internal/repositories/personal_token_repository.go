@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/config"
+	"github.com/raxaris/ipromise-backend/internal/models"
+)
+
+// CreatePersonalToken – сохраняет персональный токен
+func CreatePersonalToken(token *models.PersonalAccessToken) error {
+	return config.DB.Create(token).Error
+}
+
+// GetPersonalTokenByHash – получает токен по хешу
+func GetPersonalTokenByHash(hash string) (*models.PersonalAccessToken, error) {
+	var token models.PersonalAccessToken
+	if err := config.DB.First(&token, "token_hash = ?", hash).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// GetPersonalTokensByUserID – неотозванные токены пользователя
+func GetPersonalTokensByUserID(userID uuid.UUID) ([]models.PersonalAccessToken, error) {
+	var tokens []models.PersonalAccessToken
+	err := config.DB.Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("created_at DESC").
+		Find(&tokens).Error
+	return tokens, err
+}
+
+// TouchPersonalToken – обновляет время последнего использования
+func TouchPersonalToken(tokenID uuid.UUID, at time.Time) error {
+	return config.DB.Model(&models.PersonalAccessToken{}).Where("id = ?", tokenID).Update("last_used_at", at).Error
+}
+
+// RevokePersonalToken – отзывает токен пользователя, возвращает число затронутых записей
+func RevokePersonalToken(userID, tokenID uuid.UUID) (int64, error) {
+	res := config.DB.Model(&models.PersonalAccessToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", tokenID, userID).
+		Update("revoked_at", time.Now())
+	return res.RowsAffected, res.Error
+}
+
+// RevokeAllPersonalTokens – отзывает все действующие токены пользователя
+func RevokeAllPersonalTokens(userID uuid.UUID) error {
+	return config.DB.Model(&models.PersonalAccessToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
@@ -1,9 +1,15 @@
 package repositories
 
 import (
+	"strings"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/raxaris/ipromise-backend/config"
+	"github.com/raxaris/ipromise-backend/internal/dto"
 	"github.com/raxaris/ipromise-backend/internal/models"
+	"github.com/raxaris/ipromise-backend/internal/pagination"
+	"gorm.io/gorm"
 )
 
 // CreatePromise – создаёт обещание в БД
@@ -20,32 +26,171 @@ func GetPromiseByID(id uuid.UUID) (*models.Promise, error) {
 	return &promise, nil
 }
 
-// GetPromisesByUserID – получает все обещания конкретного пользователя
-func GetPromisesByUserID(userID uuid.UUID) ([]models.Promise, error) {
-	var promises []models.Promise
-	if err := config.DB.Where("user_id = ?", userID).Find(&promises).Error; err != nil {
-		return nil, err
+// Поля, по которым можно сортировать списки обещаний
+var promiseSortColumns = map[string]string{
+	"created_at": "created_at",
+	"deadline":   "deadline",
+}
+
+// ListPromises – страница обещаний по фильтрам
+func ListPromises(filter dto.PromiseFilter, page pagination.Page) ([]models.Promise, error) {
+	column, ok := promiseSortColumns[page.Sort]
+	if !ok {
+		return nil, pagination.ErrInvalidSort
 	}
-	return promises, nil
+
+	query := filterPromises(config.DB.Model(&models.Promise{}), filter)
+	var promises []models.Promise
+	err := paginate(query, page, column).Find(&promises).Error
+	return promises, err
 }
 
-// GetAllPromises – получение всех обещаний
-func GetAllPromises() ([]models.Promise, error) {
+// Предельная глубина дерева прогресса – защита рекурсивного запроса от циклов в parent_id
+const maxPromiseTreeDepth = 100
+
+// GetPromiseTree – основное обещание, к которому относится id (само обещание или его прогресс любой глубины),
+// и все его обновления прогресса одним рекурсивным запросом. Основное обещание идёт первым, остальные – по уровню и времени создания.
+// Пустой результат – обещания нет или цепочка до основного прервана удалённой записью.
+func GetPromiseTree(id uuid.UUID) ([]models.Promise, error) {
 	var promises []models.Promise
-	err := config.DB.Find(&promises).Error
+	err := config.DB.Raw(`
+WITH RECURSIVE ancestors AS (
+	SELECT id, parent_id, 0 AS depth FROM promises WHERE id = ? AND deleted_at IS NULL
+	UNION ALL
+	SELECT p.id, p.parent_id, a.depth + 1 FROM promises p
+	JOIN ancestors a ON p.id = a.parent_id
+	WHERE p.deleted_at IS NULL AND a.depth < ?
+), tree AS (
+	SELECT p.*, 0 AS depth FROM promises p
+	JOIN ancestors a ON p.id = a.id
+	WHERE a.parent_id IS NULL
+	UNION ALL
+	SELECT p.*, t.depth + 1 FROM promises p
+	JOIN tree t ON p.parent_id = t.id
+	WHERE p.deleted_at IS NULL AND t.depth < ?
+)
+SELECT * FROM tree ORDER BY depth, created_at, id`, id, maxPromiseTreeDepth, maxPromiseTreeDepth).Scan(&promises).Error
 	return promises, err
 }
 
-// GetPublicPromises – возвращает только публичные обещания
-func GetPublicPromises() ([]models.Promise, error) {
+// filterPromises – условия dto.PromiseFilter
+func filterPromises(query *gorm.DB, filter dto.PromiseFilter) *gorm.DB {
+	if filter.PublicOnly {
+		public := config.DB.Where("is_private = ? AND hidden_at IS NULL", false).
+			Where("user_id NOT IN (?)", RestrictedUserIDs())
+		if filter.ViewerID != nil {
+			public = public.Or("user_id = ?", *filter.ViewerID)
+		}
+		query = query.Where(public)
+	}
+	if filter.OwnerID != nil {
+		query = query.Where("user_id = ?", *filter.OwnerID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.DeadlineFrom != nil {
+		query = query.Where("deadline >= ?", *filter.DeadlineFrom)
+	}
+	if filter.DeadlineTo != nil {
+		query = query.Where("deadline < ?", *filter.DeadlineTo)
+	}
+	if filter.ParentID != nil {
+		query = query.Where("parent_id = ?", *filter.ParentID)
+	} else if filter.RootOnly {
+		query = query.Where("parent_id IS NULL")
+	}
+	return query
+}
+
+// Подсветка совпадений в фрагментах поиска
+const (
+	titleHeadlineOptions       = "StartSel=<mark>, StopSel=</mark>, HighlightAll=true"
+	descriptionHeadlineOptions = `StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=30, MinWords=10, FragmentDelimiter=" … "`
+)
+
+// SearchPromises – страница обещаний, подходящих под поисковый запрос text, с релевантностью и фрагментами.
+// Запрос разбирается в каждой конфигурации models.SearchConfigs, совпадение в любой из них считается найденным.
+// Сортировка – по релевантности (rank) или по полю created_at.
+func SearchPromises(text string, filter dto.PromiseFilter, page pagination.Page) ([]dto.PromiseSearchHit, error) {
+	queries := make([]string, len(models.SearchConfigs))
+	args := make([]interface{}, len(models.SearchConfigs))
+	for i, name := range models.SearchConfigs {
+		queries[i] = "websearch_to_tsquery('" + name + "', ?)"
+		args[i] = text
+	}
+	search := config.DB.Raw("SELECT "+strings.Join(queries, " || ")+" AS query", args...)
+
+	const rank = "ts_rank(promises.search_vector, search.query)"
+	query := config.DB.Model(&models.Promise{}).
+		Select("promises.*, "+rank+" AS rank, "+
+			searchHeadline("title", titleHeadlineOptions)+" AS title_highlight, "+
+			searchHeadline("description", descriptionHeadlineOptions)+" AS description_highlight").
+		Joins("CROSS JOIN (?) AS search", search).
+		Where("promises.search_vector @@ search.query")
+	query = filterPromises(query, filter)
+
+	switch page.Sort {
+	case "rank":
+		var after interface{}
+		if page.After != nil {
+			after = page.After.Rank
+		}
+		query = keyset(query, page, rank, after)
+	case "created_at":
+		query = paginate(query, page, "promises.created_at")
+	default:
+		return nil, pagination.ErrInvalidSort
+	}
+
+	var hits []dto.PromiseSearchHit
+	err := query.Scan(&hits).Error
+	return hits, err
+}
+
+// searchHeadline – фрагмент колонки с подсвеченными совпадениями; текст экранируется до разметки
+func searchHeadline(column, options string) string {
+	escaped := "replace(replace(replace(coalesce(promises." + column + ", ''), '&', '&amp;'), '<', '&lt;'), '>', '&gt;')"
+	return "ts_headline('" + models.SearchConfigs[0] + "', " + escaped + ", search.query, '" + options + "')"
+}
+
+// GetRecentPublicPromises – последние публичные обещания, включая скрытые (лента модерации)
+func GetRecentPublicPromises(since *time.Time, limit int) ([]models.Promise, error) {
+	query := config.DB.Where("is_private = ?", false)
+	if since != nil {
+		query = query.Where("created_at >= ?", *since)
+	}
 	var promises []models.Promise
-	err := config.DB.Where("is_private = ?", false).Find(&promises).Error
+	err := query.Order("created_at DESC").Limit(limit).Find(&promises).Error
 	return promises, err
 }
 
-// UpdatePromise – обновляет обещание (например, меняет статус)
+// SetPromiseHidden – скрывает обещание (moderatorID != nil) или снимает скрытие.
+// Возвращает false, если обещание уже в нужном состоянии.
+func SetPromiseHidden(id uuid.UUID, moderatorID *uuid.UUID, reason string) (bool, error) {
+	query := config.DB.Model(&models.Promise{}).Where("id = ?", id)
+	var updates map[string]interface{}
+	if moderatorID != nil {
+		query = query.Where("hidden_at IS NULL")
+		updates = map[string]interface{}{"hidden_at": time.Now(), "hidden_by_id": *moderatorID, "hidden_reason": reason}
+	} else {
+		query = query.Where("hidden_at IS NOT NULL")
+		updates = map[string]interface{}{"hidden_at": nil, "hidden_by_id": nil, "hidden_reason": ""}
+	}
+	res := query.Updates(updates)
+	return res.RowsAffected > 0, res.Error
+}
+
+// UpdatePromiseTitle – меняет заголовок обещания по решению модератора
+func UpdatePromiseTitle(id uuid.UUID, title string) error {
+	return config.DB.Model(&models.Promise{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"title": title, "title_moderated_at": time.Now()}).Error
+}
+
+// UpdatePromise – обновляет обещание (например, меняет статус).
+// Поля модерации меняются отдельными запросами и не перезаписываются устаревшей копией.
 func UpdatePromise(promise *models.Promise) error {
-	return config.DB.Save(promise).Error
+	return config.DB.Omit("hidden_at", "hidden_by_id", "hidden_reason", "title_moderated_at").Save(promise).Error
 }
 
 // DeletePromise – мягкое удаление обещания (soft-delete)
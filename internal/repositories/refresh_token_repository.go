@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/config"
+	"github.com/raxaris/ipromise-backend/internal/models"
+)
+
+// GetActiveRefreshTokensByUserID – получает действующие токены пользователя (по одному на сессию)
+func GetActiveRefreshTokensByUserID(userID uuid.UUID) ([]models.RefreshToken, error) {
+	var tokens []models.RefreshToken
+	err := config.DB.
+		Where("user_id = ? AND used_at IS NULL AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC").
+		Find(&tokens).Error
+	return tokens, err
+}
+
+// RevokeRefreshTokenFamily – отзывает сессию пользователя, возвращает число затронутых токенов
+func RevokeRefreshTokenFamily(userID, familyID uuid.UUID) (int64, error) {
+	res := config.DB.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND family_id = ? AND revoked_at IS NULL", userID, familyID).
+		Update("revoked_at", time.Now())
+	return res.RowsAffected, res.Error
+}
+
+// RevokeAllRefreshTokens – отзывает все сессии пользователя
+func RevokeAllRefreshTokens(userID uuid.UUID) error {
+	return config.DB.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
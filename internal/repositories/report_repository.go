@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/config"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReportQueueRow – объект в очереди модерации со сводкой открытых жалоб
+type ReportQueueRow struct {
+	TargetType      string
+	TargetID        uuid.UUID
+	ReportCount     int
+	Reasons         string // Причины через запятую
+	FirstReportedAt time.Time
+	LastReportedAt  time.Time
+}
+
+// CreateReport – сохраняет жалобу. Возвращает false, если у автора уже есть открытая жалоба на этот объект.
+func CreateReport(report *models.Report) (bool, error) {
+	res := config.DB.Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "reporter_id"}, {Name: "target_type"}, {Name: "target_id"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "status = 'open'"}}},
+		DoNothing:   true,
+	}).Create(report)
+	return res.RowsAffected > 0, res.Error
+}
+
+// GetReportQueue – объекты с открытыми жалобами, первыми – с самыми старыми жалобами
+func GetReportQueue(targetType string, limit int) ([]ReportQueueRow, error) {
+	query := config.DB.Model(&models.Report{}).
+		Select("target_type, target_id, COUNT(*) AS report_count, "+
+			"STRING_AGG(DISTINCT reason, ',') AS reasons, "+
+			"MIN(created_at) AS first_reported_at, MAX(created_at) AS last_reported_at").
+		Where("status = ?", models.ReportStatusOpen)
+	if targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+	}
+
+	var items []ReportQueueRow
+	err := query.Group("target_type, target_id").
+		Order("first_reported_at").
+		Limit(limit).
+		Scan(&items).Error
+	return items, err
+}
+
+// GetOpenReports – открытые жалобы на объект
+func GetOpenReports(targetType string, targetID uuid.UUID) ([]models.Report, error) {
+	var reports []models.Report
+	err := config.DB.Where("target_type = ? AND target_id = ? AND status = ?", targetType, targetID, models.ReportStatusOpen).
+		Order("created_at").
+		Find(&reports).Error
+	return reports, err
+}
+
+// CreateReportDecision – сохраняет решение и закрывает им все открытые жалобы на объект, затем вызывает apply
+// (применение решения). Жалобы остаются заблокированными до конца транзакции, поэтому параллельное решение
+// по тому же объекту ждёт и не находит открытых жалоб; ошибка apply откатывает решение.
+// Возвращает false, если открытых жалоб уже нет (например, их закрыл другой модератор) – apply тогда не вызывается.
+func CreateReportDecision(decision *models.ReportDecision, apply func() error) (bool, error) {
+	resolved := false
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(decision).Error; err != nil {
+			return err
+		}
+
+		res := tx.Model(&models.Report{}).
+			Where("target_type = ? AND target_id = ? AND status = ?", decision.TargetType, decision.TargetID, models.ReportStatusOpen).
+			Updates(map[string]interface{}{"status": models.ReportStatusResolved, "decision_id": decision.ID})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if apply != nil {
+			if err := apply(); err != nil {
+				return err
+			}
+		}
+
+		decision.ReportCount = int(res.RowsAffected)
+		resolved = true
+		return tx.Model(decision).Update("report_count", decision.ReportCount).Error
+	})
+	if err == gorm.ErrRecordNotFound {
+		return false, nil
+	}
+	return resolved && err == nil, err
+}
+
+// GetReportDecisions – история решений, новые первыми; targetID – фильтр по объекту
+func GetReportDecisions(targetType string, targetID *uuid.UUID, limit int) ([]models.ReportDecision, error) {
+	query := config.DB.Model(&models.ReportDecision{})
+	if targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+	}
+	if targetID != nil {
+		query = query.Where("target_id = ?", *targetID)
+	}
+
+	var decisions []models.ReportDecision
+	err := query.Order("created_at DESC").Limit(limit).Find(&decisions).Error
+	return decisions, err
+}
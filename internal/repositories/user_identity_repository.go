@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/raxaris/ipromise-backend/config"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetUserIdentity – привязка по провайдеру и `sub`
+func GetUserIdentity(provider, subject string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	if err := config.DB.First(&identity, "provider = ? AND subject = ?", provider, subject).Error; err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// CreateUserIdentity – привязывает внешний аккаунт к существующему пользователю
+func CreateUserIdentity(identity *models.UserIdentity) error {
+	return config.DB.Create(identity).Error
+}
+
+// CreateUserWithIdentity – создаёт пользователя вместе с привязкой внешнего аккаунта
+func CreateUserWithIdentity(user *models.User, identity *models.UserIdentity) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		identity.UserID = user.ID
+		return tx.Create(identity).Error
+	})
+}
+
+// CreateOIDCAuthState – сохраняет state незавершённого входа
+func CreateOIDCAuthState(state *models.OIDCAuthState) error {
+	// Заодно убираем брошенные входы
+	config.DB.Where("expires_at < ?", time.Now()).Delete(&models.OIDCAuthState{})
+	return config.DB.Create(state).Error
+}
+
+// ConsumeOIDCAuthState – удаляет и возвращает state; повторно его использовать нельзя
+func ConsumeOIDCAuthState(stateHash, provider string) (*models.OIDCAuthState, error) {
+	var states []models.OIDCAuthState
+	err := config.DB.Clauses(clause.Returning{}).
+		Where("state_hash = ? AND provider = ? AND expires_at > ?", stateHash, provider, time.Now()).
+		Delete(&states).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(states) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &states[0], nil
+}
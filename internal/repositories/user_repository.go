@@ -1,9 +1,14 @@
 package repositories
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/raxaris/ipromise-backend/config"
 	"github.com/raxaris/ipromise-backend/internal/models"
+	"github.com/raxaris/ipromise-backend/internal/pagination"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // CreateUser – создание пользователя в БД
@@ -31,16 +36,51 @@ func GetUserByUsername(username string) (*models.User, error) {
 	return &user, nil
 }
 
-// GetAllUsers – получение списка всех пользователей
-func GetAllUsers() ([]models.User, error) {
+// ListUsers – страница пользователей (сортировка по дате регистрации), role – необязательный фильтр
+func ListUsers(role string, page pagination.Page) ([]models.User, error) {
+	if page.Sort != "created_at" {
+		return nil, pagination.ErrInvalidSort
+	}
+
+	query := config.DB.Model(&models.User{})
+	if role != "" {
+		query = query.Where("role = ?", role)
+	}
+
 	var users []models.User
-	err := config.DB.Find(&users).Error
+	err := paginate(query, page, "created_at").Find(&users).Error
 	return users, err
 }
 
-// UpdateUser – обновление пользователя
+// UpdateUser – обновление пользователя (версия токенов и счётчики входа меняются отдельными запросами)
 func UpdateUser(user *models.User) error {
-	return config.DB.Save(user).Error
+	return config.DB.Omit("token_version", "failed_logins", "locked_until", "suspended_at", "suspended_until", "suspended_by_id", "suspended_reason").Save(user).Error
+}
+
+// ReplacePasswordHash – меняет хеш пароля, если он всё ещё равен oldHash
+func ReplacePasswordHash(userID uuid.UUID, oldHash, newHash string) (bool, error) {
+	res := config.DB.Model(&models.User{}).
+		Where("id = ? AND password = ?", userID, oldHash).
+		Update("password", newHash)
+	return res.RowsAffected > 0, res.Error
+}
+
+// SuspendUser – ограничивает аккаунт до until (nil – бессрочный бан)
+func SuspendUser(userID, actorID uuid.UUID, reason string, until *time.Time) error {
+	return config.DB.Model(&models.User{}).Where("id = ?", userID).
+		Updates(map[string]interface{}{"suspended_at": time.Now(), "suspended_until": until, "suspended_by_id": actorID, "suspended_reason": reason}).Error
+}
+
+// LiftUserRestriction – снимает приостановку или бан
+func LiftUserRestriction(userID uuid.UUID) error {
+	return config.DB.Model(&models.User{}).Where("id = ?", userID).
+		Updates(map[string]interface{}{"suspended_at": nil, "suspended_until": nil, "suspended_by_id": nil, "suspended_reason": ""}).Error
+}
+
+// RestrictedUserIDs – подзапрос ID пользователей, на которых сейчас действует приостановка или бан
+func RestrictedUserIDs() *gorm.DB {
+	return config.DB.Model(&models.User{}).Select("id").
+		Where("suspended_at IS NOT NULL AND (suspended_until IS NULL OR suspended_until > ?)", time.Now())
 }
 
 // DeleteUser – удаление пользователя
@@ -61,3 +101,32 @@ func IsUsernameExists(username string) bool {
 	config.DB.Model(&models.User{}).Where("username = ?", username).Count(&count)
 	return count > 0
 }
+
+// GetUserAccessState – роль, версия токенов и ограничения аккаунта (то, что проверяется на каждом запросе)
+func GetUserAccessState(userID uuid.UUID) (*models.User, error) {
+	var user models.User
+	err := config.DB.Select("id", "role", "token_version", "suspended_at", "suspended_until").First(&user, "id = ?", userID).Error
+	return &user, err
+}
+
+// IncrementTokenVersion – увеличивает версию токенов и возвращает новое значение
+func IncrementTokenVersion(userID uuid.UUID) (int, error) {
+	var user models.User
+	res := config.DB.Model(&user).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "token_version"}}}).
+		Where("id = ?", userID).
+		Update("token_version", gorm.Expr("token_version + 1"))
+	if res.Error == nil && res.RowsAffected == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return user.TokenVersion, res.Error
+}
+
+// GetUserByEmail – получение пользователя по email
+func GetUserByEmail(email string) (*models.User, error) {
+	var user models.User
+	if err := config.DB.First(&user, "email = ?", email).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+	"time"
+
+	"github.com/raxaris/ipromise-backend/config"
+	"github.com/raxaris/ipromise-backend/internal/dto"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"github.com/raxaris/ipromise-backend/internal/repositories"
+)
+
+const (
+	maxAuditLogPage        = 500
+	auditRetentionInterval = 24 * time.Hour // Как часто удалять записи старше AUDIT_RETENTION
+)
+
+// Поля, изменение которых не интересно журналу аудита
+var auditIgnoredFields = map[string]bool{"UpdatedAt": true, "updated_at": true}
+
+// RecordAudit – добавляет запись в журнал аудита. Ошибка записи не прерывает действие, но попадает в лог.
+func RecordAudit(entry *models.AuditLog) {
+	if err := repositories.CreateAuditLog(entry); err != nil {
+		log.Printf("❌ Ошибка записи в журнал аудита (%s): %v", entry.Action, err)
+	}
+}
+
+// AuditDiff – поля, различающиеся в JSON-представлениях before и after (nil – объекта не было или не стало)
+func AuditDiff(before, after interface{}) models.AuditChanges {
+	from, to := auditFields(before), auditFields(after)
+
+	changes := models.AuditChanges{}
+	for key, value := range from {
+		if auditIgnoredFields[key] {
+			continue
+		}
+		if next, ok := to[key]; !ok || !reflect.DeepEqual(value, next) {
+			changes[key] = models.AuditChange{From: value, To: to[key]}
+		}
+	}
+	for key, value := range to {
+		if _, ok := from[key]; !ok && !auditIgnoredFields[key] {
+			changes[key] = models.AuditChange{To: value}
+		}
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
+
+// auditFields – объект в виде map по его JSON-тегам; скрытые из JSON поля (пароль, секреты) не попадают в журнал
+func auditFields(value interface{}) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if value == nil {
+		return fields
+	}
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Pointer && v.IsNil() {
+		return fields
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fields
+	}
+	_ = json.Unmarshal(data, &fields)
+	return fields
+}
+
+// GetAuditLogs – журнал аудита по фильтрам (для админов)
+func GetAuditLogs(filter dto.AuditLogFilter) ([]models.AuditLog, error) {
+	if filter.Limit <= 0 || filter.Limit > maxAuditLogPage {
+		filter.Limit = maxAuditLogPage
+	}
+	return repositories.GetAuditLogs(filter)
+}
+
+// StartAuditRetention – фоновая очистка журнала аудита по сроку хранения AUDIT_RETENTION
+func StartAuditRetention() {
+	if config.AuditRetention <= 0 {
+		return
+	}
+
+	go func() {
+		for {
+			deleted, err := repositories.PurgeAuditLogs(time.Now().Add(-config.AuditRetention))
+			if err != nil {
+				log.Println("❌ Ошибка очистки журнала аудита:", err)
+			} else if deleted > 0 {
+				log.Printf("🧹 Из журнала аудита удалено записей: %d", deleted)
+			}
+			time.Sleep(auditRetentionInterval)
+		}
+	}()
+}
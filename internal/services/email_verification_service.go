@@ -0,0 +1,180 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/config"
+	"github.com/raxaris/ipromise-backend/internal/mailer"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"github.com/raxaris/ipromise-backend/internal/repositories"
+)
+
+// Ошибки
+var (
+	ErrInvalidVerificationToken = errors.New("ссылка для подтверждения email недействительна или устарела")
+	ErrEmailAlreadyVerified     = errors.New("email уже подтверждён")
+	ErrEmailNotVerified         = errors.New("подтвердите email, чтобы публиковать обещания")
+	ErrSameEmail                = errors.New("новый email совпадает с текущим")
+	ErrVerificationThrottled    = errors.New("слишком много писем подтверждения, попробуйте позже")
+)
+
+const emailVerificationTTL = 24 * time.Hour
+
+// SendEmailVerification – отправляет письмо со ссылкой подтверждения текущего email пользователя
+func SendEmailVerification(user *models.User) error {
+	if user.IsEmailVerified() {
+		return ErrEmailAlreadyVerified
+	}
+
+	tokenString, err := generateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	token := models.EmailVerificationToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Email:     user.Email,
+		TokenHash: hashOpaqueToken(tokenString),
+		ExpiresAt: time.Now().Add(emailVerificationTTL),
+	}
+	if err := repositories.CreateEmailVerificationToken(&token); err != nil {
+		return err
+	}
+
+	sendMail(mailer.Message{
+		To:      user.Email,
+		Subject: "Подтверждение email в iPromise",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\nПодтвердите адрес, перейдя по ссылке:\n%s\n\n"+
+			"Ссылка действует %d часа.",
+			user.Username, appLink("/verify-email?token="+url.QueryEscape(tokenString)), int(emailVerificationTTL.Hours())),
+	})
+	return nil
+}
+
+// RequestEmailChange – после проверки пароля отправляет ссылку подтверждения на новый адрес
+// и предупреждает старый. Email меняется только после перехода по ссылке (VerifyEmail).
+func RequestEmailChange(userID uuid.UUID, newEmail, currentPassword, ip string) error {
+	user, err := GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := CheckAccountAllowed(user); err != nil {
+		return err
+	}
+	if !user.CheckPassword(currentPassword) {
+		RegisterLoginFailure(user, user.Email, ip, models.LoginFailureBadPassword)
+		return ErrInvalidCurrentPassword
+	}
+
+	newEmail = strings.TrimSpace(newEmail)
+	if strings.EqualFold(newEmail, user.Email) {
+		return ErrSameEmail
+	}
+	if repositories.IsEmailExists(newEmail) {
+		return ErrEmailTaken
+	}
+
+	tokenString, err := generateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	token := models.EmailVerificationToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Email:     newEmail,
+		TokenHash: hashOpaqueToken(tokenString),
+		ExpiresAt: time.Now().Add(emailVerificationTTL),
+	}
+	if err := repositories.CreateEmailVerificationToken(&token); err != nil {
+		return err
+	}
+
+	sendMail(mailer.Message{
+		To:      newEmail,
+		Subject: "Подтверждение нового email в iPromise",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\nЧтобы сделать этот адрес основным для аккаунта, перейдите по ссылке:\n%s\n\n"+
+			"Ссылка действует %d часа.",
+			user.Username, appLink("/verify-email?token="+url.QueryEscape(tokenString)), int(emailVerificationTTL.Hours())),
+	})
+	sendMail(mailer.Message{
+		To:      user.Email,
+		Subject: "Смена email в iPromise",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\nДля вашего аккаунта запрошена смена email на %s.\n"+
+			"Адрес изменится после подтверждения по ссылке, отправленной на новый email.\n"+
+			"Если это были не вы, смените пароль и завершите все сессии.",
+			user.Username, newEmail),
+	})
+	return nil
+}
+
+// ResendEmailVerification – повторная отправка письма подтверждения.
+// Сверх EMAIL_VERIFICATION_MAX_PER_USER писем за окно возвращает MailThrottledError.
+func ResendEmailVerification(userID uuid.UUID) error {
+	user, err := GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.IsEmailVerified() {
+		return ErrEmailAlreadyVerified
+	}
+
+	since := time.Now().Add(-config.EmailVerificationWindow)
+	count, err := repositories.CountEmailVerificationsByUser(userID, since)
+	if err != nil {
+		return err
+	}
+	if count >= int64(config.EmailVerificationMaxPerUser) {
+		// Окно скользящее: отправка откроется, когда из него выпадет самое раннее письмо
+		retryAfter := config.EmailVerificationWindow
+		if oldest, err := repositories.GetOldestEmailVerificationByUser(userID, since); err == nil {
+			retryAfter = time.Until(oldest.CreatedAt.Add(config.EmailVerificationWindow))
+		}
+		return &MailThrottledError{Reason: ErrVerificationThrottled, RetryAfter: retryAfter}
+	}
+	return SendEmailVerification(user)
+}
+
+// VerifyEmail – подтверждает адрес по токену из письма
+func VerifyEmail(tokenString string) error {
+	token, err := repositories.GetEmailVerificationTokenByHash(hashOpaqueToken(tokenString))
+	if err != nil || token.UsedAt != nil || time.Now().After(token.ExpiresAt) {
+		return ErrInvalidVerificationToken
+	}
+
+	// Пока письмо шло, адрес мог занять другой аккаунт
+	if user, err := GetUserByID(token.UserID); err == nil && user.Email != token.Email && repositories.IsEmailExists(token.Email) {
+		return ErrEmailTaken
+	}
+
+	consumed, err := repositories.ConsumeEmailVerificationToken(token)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidVerificationToken
+	}
+	return nil
+}
+
+// ensureCanPublish – проверяет, может ли пользователь публиковать обещания (REQUIRE_VERIFIED_EMAIL_FOR_PUBLIC)
+func ensureCanPublish(userID uuid.UUID) error {
+	if !config.RequireVerifiedEmailForPublic {
+		return nil
+	}
+	user, err := repositories.GetUserByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if !user.IsEmailVerified() {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
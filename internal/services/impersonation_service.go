@@ -0,0 +1,190 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/config"
+	"github.com/raxaris/ipromise-backend/internal/authz"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"github.com/raxaris/ipromise-backend/internal/repositories"
+	"gorm.io/gorm"
+)
+
+// Ошибки
+var (
+	ErrCannotImpersonateSelf  = errors.New("нельзя войти под собственным аккаунтом")
+	ErrCannotImpersonateStaff = errors.New("нельзя войти под аккаунтом администратора")
+	ErrImpersonationRevoked   = errors.New("токен имперсонации отозван")
+	ErrImpersonationNotFound  = errors.New("токен имперсонации не найден")
+)
+
+const (
+	maxImpersonationLogPage = 500
+	maxImpersonationLogPath = 500 // Размер колонки path
+)
+
+// Impersonation – выданный администратору токен для работы от имени пользователя
+type Impersonation struct {
+	AccessToken string    `json:"access_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	UserID      uuid.UUID `json:"user_id"`
+}
+
+// ImpersonateUser – выдаёт короткоживущий Access-токен пользователя с claim `act` (без Refresh-токена).
+// Под аккаунтами, которые сами могут входить под другими, войти нельзя.
+func ImpersonateUser(actor authz.Actor, userID uuid.UUID, amr []string, ip string) (*Impersonation, error) {
+	if actor.ID == userID {
+		return nil, ErrCannotImpersonateSelf
+	}
+
+	user, err := GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if authz.Can(authz.Actor{ID: user.ID, Role: user.Role}, authz.UserImpersonate, nil) {
+		return nil, ErrCannotImpersonateStaff
+	}
+	// Версия токенов администратора попадает в токен: его разжалование или отзыв его токенов завершают и имперсонацию
+	impersonator, err := GetUserByID(actor.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(config.ImpersonationTTL)
+	claims := AccessClaims{
+		Role:    user.Role,
+		Version: user.TokenVersion,
+		AMR:     amr, // Способы входа администратора
+		Act:     &ActClaim{Subject: actor.ID.String(), Version: impersonator.TokenVersion},
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    config.JWTIssuer,
+			Subject:   user.ID.String(),
+			Audience:  jwt.ClaimStrings{config.JWTAudience},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        uuid.NewString(),
+		},
+	}
+	token, err := signToken(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := models.ImpersonationLog{
+		ID:             uuid.New(),
+		TokenID:        claims.ID,
+		ImpersonatorID: actor.ID,
+		UserID:         user.ID,
+		Event:          models.ImpersonationStarted,
+		IP:             ip,
+	}
+	// Без записи в журнале токен не выдаём
+	if err := repositories.CreateImpersonationLog(&entry); err != nil {
+		return nil, err
+	}
+
+	return &Impersonation{AccessToken: token, ExpiresAt: expiresAt, UserID: user.ID}, nil
+}
+
+// CheckImpersonation – проверяет на каждом запросе, что токен имперсонации ещё действует: администратор активен,
+// его токены не отозваны, право входить под пользователями у него осталось и токен не завершён досрочно
+func CheckImpersonation(claims *AccessClaims) (uuid.UUID, error) {
+	impersonatorID, err := uuid.Parse(claims.Act.Subject)
+	if err != nil {
+		return uuid.Nil, ErrImpersonationRevoked
+	}
+
+	entry, err := cachedTokenVersion(impersonatorID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if entry.missing || entry.version != claims.Act.Version {
+		return uuid.Nil, ErrImpersonationRevoked
+	}
+	if CheckAccountActive(entry.user) != nil {
+		return uuid.Nil, ErrImpersonationRevoked
+	}
+	if !authz.Can(authz.Actor{ID: impersonatorID, Role: entry.user.Role}, authz.UserImpersonate, nil) {
+		return uuid.Nil, ErrImpersonationRevoked
+	}
+
+	ended, err := repositories.IsImpersonationEnded(claims.ID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if ended {
+		return uuid.Nil, ErrImpersonationRevoked
+	}
+	return impersonatorID, nil
+}
+
+// EndImpersonation – досрочно отзывает токен имперсонации по jti. Повторный вызов ничего не меняет.
+func EndImpersonation(tokenID, ip string) (*models.ImpersonationLog, error) {
+	started, err := repositories.GetImpersonationStart(tokenID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrImpersonationNotFound
+		}
+		return nil, err
+	}
+
+	ended, err := repositories.IsImpersonationEnded(tokenID)
+	if err != nil || ended {
+		return started, err
+	}
+
+	entry := models.ImpersonationLog{
+		ID:             uuid.New(),
+		TokenID:        tokenID,
+		ImpersonatorID: started.ImpersonatorID,
+		UserID:         started.UserID,
+		Event:          models.ImpersonationEnded,
+		IP:             ip,
+	}
+	return started, repositories.CreateImpersonationLog(&entry)
+}
+
+// RecordImpersonatedRequest – записывает запрос, выполненный по токену имперсонации
+func RecordImpersonatedRequest(claims *AccessClaims, method, path string, status int, ip string) {
+	impersonatorID, err := uuid.Parse(claims.Act.Subject)
+	if err != nil {
+		return
+	}
+	userID, err := claims.UserID()
+	if err != nil {
+		return
+	}
+
+	if len(path) > maxImpersonationLogPath {
+		path = path[:maxImpersonationLogPath]
+	}
+
+	entry := models.ImpersonationLog{
+		ID:             uuid.New(),
+		TokenID:        claims.ID,
+		ImpersonatorID: impersonatorID,
+		UserID:         userID,
+		Event:          models.ImpersonationRequest,
+		Method:         method,
+		Path:           path,
+		Status:         status,
+		IP:             ip,
+	}
+	if err := repositories.CreateImpersonationLog(&entry); err != nil {
+		log.Println("❌ Ошибка записи в журнал имперсонации:", err)
+	}
+}
+
+// GetImpersonationLogs – журнал имперсонации (для админов)
+func GetImpersonationLogs(impersonatorID, userID *uuid.UUID, tokenID string, limit int) ([]models.ImpersonationLog, error) {
+	if limit <= 0 || limit > maxImpersonationLogPage {
+		limit = maxImpersonationLogPage
+	}
+	return repositories.GetImpersonationLogs(impersonatorID, userID, tokenID, limit)
+}
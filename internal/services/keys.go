@@ -0,0 +1,256 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/raxaris/ipromise-backend/config"
+)
+
+// Ошибки
+var (
+	ErrUnknownSigningKey = errors.New("неизвестный ключ подписи токена")
+)
+
+// jwtKey – ключ подписи или проверки JWT
+type jwtKey struct {
+	kid    string
+	method jwt.SigningMethod
+	sign   interface{} // Приватный ключ (или секрет для HS256), nil у ключей только для проверки
+	verify interface{} // Публичный ключ (или секрет для HS256)
+}
+
+var (
+	signingKey       *jwtKey
+	verificationKeys = map[string]*jwtKey{}
+)
+
+// JWK – публичный ключ в формате JSON Web Key
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// LoadSigningKeys – загружает активный ключ подписи и ключи проверки из конфигурации.
+// HS256 использует JWT_SECRET, RS256/EdDSA – PEM-файлы; старые публичные ключи
+// остаются в наборе проверки, пока не истекут выданные ими токены.
+func LoadSigningKeys() error {
+	signingKey = nil
+	verificationKeys = map[string]*jwtKey{}
+
+	switch config.JWTAlgorithm {
+	case "HS256":
+		signingKey = &jwtKey{
+			kid:    config.JWTKeyID,
+			method: jwt.SigningMethodHS256,
+			sign:   []byte(config.JWTSecret),
+			verify: []byte(config.JWTSecret),
+		}
+	case "RS256", "EdDSA":
+		key, err := loadPrivateKey(config.JWTPrivateKeyFile, config.JWTKeyID)
+		if err != nil {
+			return err
+		}
+		if key.method.Alg() != config.JWTAlgorithm {
+			return fmt.Errorf("ключ %s не подходит для алгоритма %s", config.JWTPrivateKeyFile, config.JWTAlgorithm)
+		}
+		signingKey = key
+	default:
+		return fmt.Errorf("неподдерживаемый алгоритм подписи JWT: %s", config.JWTAlgorithm)
+	}
+	verificationKeys[signingKey.kid] = signingKey
+
+	// Дополнительные ключи проверки: "kid=path" или просто "path"
+	for _, entry := range config.JWTPublicKeyFiles {
+		kid, path := "", entry
+		if i := strings.Index(entry, "="); i >= 0 {
+			kid, path = entry[:i], entry[i+1:]
+		}
+		key, err := loadPublicKey(path, kid)
+		if err != nil {
+			return err
+		}
+		if _, exists := verificationKeys[key.kid]; exists {
+			return fmt.Errorf("ключ с kid %q указан дважды", key.kid)
+		}
+		verificationKeys[key.kid] = key
+	}
+
+	return nil
+}
+
+// lookupVerificationKey – ключ проверки для токена по заголовку kid и алгоритму
+func lookupVerificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := verificationKeys[kid]
+	if !ok {
+		return nil, ErrUnknownSigningKey
+	}
+	// Алгоритм задаёт ключ, а не заголовок токена
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, jwt.ErrTokenSignatureInvalid
+	}
+	return key.verify, nil
+}
+
+// verificationMethods – алгоритмы, которые допускаются при проверке токенов
+func verificationMethods() []string {
+	seen := map[string]bool{}
+	var methods []string
+	for _, key := range verificationKeys {
+		if alg := key.method.Alg(); !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+	return methods
+}
+
+// signToken – подписывает claims активным ключом и проставляет kid
+func signToken(claims jwt.Claims) (string, error) {
+	if signingKey == nil {
+		return "", ErrUnknownSigningKey
+	}
+	token := jwt.NewWithClaims(signingKey.method, claims)
+	if signingKey.kid != "" {
+		token.Header["kid"] = signingKey.kid
+	}
+	return token.SignedString(signingKey.sign)
+}
+
+// GetJWKS – публичные ключи проверки для /.well-known/jwks.json (секреты HS256 не публикуются)
+func GetJWKS() []JWK {
+	keys := make([]JWK, 0, len(verificationKeys))
+	for _, key := range verificationKeys {
+		switch pub := key.verify.(type) {
+		case *rsa.PublicKey:
+			keys = append(keys, JWK{
+				Kty: "RSA",
+				Kid: key.kid,
+				Use: "sig",
+				Alg: key.method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			keys = append(keys, JWK{
+				Kty: "OKP",
+				Kid: key.kid,
+				Use: "sig",
+				Alg: key.method.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Kid < keys[j].Kid })
+	return keys
+}
+
+// loadPrivateKey – читает приватный RSA/Ed25519 ключ из PEM-файла
+func loadPrivateKey(path, kid string) (*jwtKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed interface{}
+	if block.Type == "RSA PRIVATE KEY" {
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	} else {
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора ключа %s: %w", path, err)
+	}
+
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("ключ %s не поддерживает подпись", path)
+	}
+	key, err := newJWTKey(signer.Public(), kid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	key.sign = parsed
+	return key, nil
+}
+
+// loadPublicKey – читает публичный RSA/Ed25519 ключ из PEM-файла
+func loadPublicKey(path, kid string) (*jwtKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed interface{}
+	if block.Type == "RSA PUBLIC KEY" {
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	} else {
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора ключа %s: %w", path, err)
+	}
+
+	key, err := newJWTKey(parsed, kid)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+// newJWTKey – определяет алгоритм по типу ключа; без явного kid берётся отпечаток ключа
+func newJWTKey(pub interface{}, kid string) (*jwtKey, error) {
+	key := &jwtKey{kid: kid, verify: pub}
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		key.method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, errors.New("поддерживаются только ключи RSA и Ed25519")
+	}
+
+	if key.kid == "" {
+		der, err := x509.MarshalPKIXPublicKey(pub)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(der)
+		key.kid = hex.EncodeToString(sum[:8])
+	}
+	return key, nil
+}
+
+// readPEM – читает первый PEM-блок файла
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения ключа %s: %w", path, err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("файл %s не содержит PEM-блок", path)
+	}
+	return block, nil
+}
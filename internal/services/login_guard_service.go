@@ -0,0 +1,163 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/config"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"github.com/raxaris/ipromise-backend/internal/repositories"
+)
+
+// Ошибки
+var (
+	ErrAccountLocked = errors.New("слишком много неудачных попыток входа, аккаунт временно заблокирован")
+	ErrIPThrottled   = errors.New("слишком много неудачных попыток входа с вашего адреса, попробуйте позже")
+)
+
+const (
+	maxLoginAttemptsPage          = 500
+	loginAttemptRetentionInterval = time.Hour // Как часто удалять попытки старше LOGIN_ATTEMPT_RETENTION
+)
+
+// LoginBlockedError – вход временно запрещён; RetryAfter – через сколько можно повторить
+type LoginBlockedError struct {
+	Reason     error
+	RetryAfter time.Duration
+}
+
+func (e *LoginBlockedError) Error() string { return e.Reason.Error() }
+func (e *LoginBlockedError) Unwrap() error { return e.Reason }
+
+// CheckIPAllowed – не превышен ли лимит неудачных входов с IP за окно LOGIN_IP_WINDOW
+func CheckIPAllowed(ip string) error {
+	since := time.Now().Add(-config.LoginIPWindow)
+	count, err := repositories.CountFailedLoginsByIP(ip, since)
+	if err != nil {
+		return err
+	}
+	if count < int64(config.LoginIPMaxFailures) {
+		return nil
+	}
+
+	// Окно скользящее: вход откроется, когда из него выпадет самая ранняя неудача
+	retryAfter := config.LoginIPWindow
+	if oldest, err := repositories.GetOldestFailedLoginByIP(ip, since); err == nil {
+		retryAfter = time.Until(oldest.CreatedAt.Add(config.LoginIPWindow))
+	}
+	return &LoginBlockedError{Reason: ErrIPThrottled, RetryAfter: retryAfter}
+}
+
+// CheckAccountAllowed – не заблокирован ли вход в аккаунт
+func CheckAccountAllowed(user *models.User) error {
+	if err := CheckAccountActive(user); err != nil {
+		return err
+	}
+	return CheckAccountLockout(user)
+}
+
+// CheckAccountLockout – не заблокирован ли аккаунт после неудачных попыток входа.
+// В отличие от CheckAccountActive не раскрывает причину приостановки, поэтому допустима до проверки пароля.
+func CheckAccountLockout(user *models.User) error {
+	now := time.Now()
+	if user.IsLocked(now) {
+		return &LoginBlockedError{Reason: ErrAccountLocked, RetryAfter: user.LockedUntil.Sub(now)}
+	}
+	return nil
+}
+
+// RegisterLoginFailure – записывает неудачу и при превышении порога блокирует аккаунт.
+// Каждая следующая неудача после порога удваивает блокировку (до LOGIN_LOCKOUT_MAX).
+func RegisterLoginFailure(user *models.User, email, ip, reason string) {
+	attempt := models.LoginAttempt{ID: uuid.New(), Email: normalizeEmail(email), IP: ip, Reason: reason}
+	if user != nil {
+		attempt.UserID = &user.ID
+	}
+	if err := repositories.CreateLoginAttempt(&attempt); err != nil {
+		log.Println("❌ Ошибка записи попытки входа:", err)
+	}
+
+	if user == nil || reason == models.LoginFailureLocked || reason == models.LoginFailureIPThrottled {
+		return
+	}
+
+	failures, err := repositories.IncrementFailedLogins(user.ID)
+	if err != nil {
+		log.Println("❌ Ошибка обновления счётчика входов:", err)
+		return
+	}
+	if failures < config.LoginMaxFailures {
+		return
+	}
+
+	lockout := config.LoginLockoutBase
+	for i := config.LoginMaxFailures; i < failures && lockout < config.LoginLockoutMax; i++ {
+		lockout *= 2
+	}
+	if lockout > config.LoginLockoutMax {
+		lockout = config.LoginLockoutMax
+	}
+
+	if err := repositories.LockUser(user.ID, time.Now().Add(lockout)); err != nil {
+		log.Println("❌ Ошибка блокировки аккаунта:", err)
+	}
+}
+
+// RegisterLoginSuccess – записывает успешный вход и сбрасывает счётчик неудач
+func RegisterLoginSuccess(user *models.User, ip string) {
+	attempt := models.LoginAttempt{ID: uuid.New(), UserID: &user.ID, Email: normalizeEmail(user.Email), IP: ip, Success: true}
+	if err := repositories.CreateLoginAttempt(&attempt); err != nil {
+		log.Println("❌ Ошибка записи попытки входа:", err)
+	}
+
+	if user.FailedLogins > 0 || user.LockedUntil != nil {
+		if err := repositories.ResetFailedLogins(user.ID); err != nil {
+			log.Println("❌ Ошибка сброса счётчика входов:", err)
+		}
+	}
+}
+
+// UnlockUser – снимает блокировку входа (для админов)
+func UnlockUser(userID uuid.UUID) error {
+	if _, err := GetUserByID(userID); err != nil {
+		return err
+	}
+	return repositories.ResetFailedLogins(userID)
+}
+
+// GetLoginAttempts – журнал попыток входа для админки
+func GetLoginAttempts(email, ip string, userID *uuid.UUID, onlyFailed bool, limit int) ([]models.LoginAttempt, error) {
+	if limit <= 0 || limit > maxLoginAttemptsPage {
+		limit = maxLoginAttemptsPage
+	}
+	return repositories.GetLoginAttempts(normalizeEmail(email), ip, userID, onlyFailed, limit)
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// StartLoginAttemptRetention – фоновая очистка журнала попыток входа по сроку хранения LOGIN_ATTEMPT_RETENTION.
+// Срок не бывает короче окна LOGIN_IP_WINDOW, иначе ограничение по IP перестанет видеть свежие неудачи.
+func StartLoginAttemptRetention() {
+	retention := config.LoginAttemptRetention
+	if retention <= 0 {
+		return
+	}
+	retention = max(retention, config.LoginIPWindow)
+
+	go func() {
+		for {
+			deleted, err := repositories.PurgeLoginAttempts(time.Now().Add(-retention))
+			if err != nil {
+				log.Println("❌ Ошибка очистки журнала попыток входа:", err)
+			} else if deleted > 0 {
+				log.Printf("🧹 Из журнала попыток входа удалено записей: %d", deleted)
+			}
+			time.Sleep(loginAttemptRetentionInterval)
+		}
+	}()
+}
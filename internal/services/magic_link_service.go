@@ -0,0 +1,120 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/config"
+	"github.com/raxaris/ipromise-backend/internal/mailer"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"github.com/raxaris/ipromise-backend/internal/repositories"
+)
+
+// Ошибки
+var (
+	ErrInvalidMagicLink = errors.New("ссылка для входа недействительна или устарела")
+)
+
+// AuthMethodMagicLink – вход по ссылке из письма (значение `amr`)
+const AuthMethodMagicLink = "email"
+
+const magicLinkAudience = "ipromise-magic-link" // Ссылку нельзя использовать как Access-токен и наоборот
+
+// RequestMagicLink – отправляет ссылку входа, если email зарегистрирован.
+// Сверх лимита на email ссылка молча не отправляется, чтобы ответ не выдавал существование аккаунта;
+// лимит на IP возвращает MailThrottledError.
+func RequestMagicLink(email, ip string) error {
+	if err := checkMailRequestIP(models.MailRequestMagicLink, ip, config.MagicLinkWindow, config.MagicLinkMaxPerIP); err != nil {
+		return err
+	}
+
+	user, err := repositories.GetUserByEmail(strings.TrimSpace(email))
+	if err != nil || user.IsLocked(time.Now()) {
+		return nil
+	}
+
+	count, err := repositories.CountMagicLinksByUser(user.ID, time.Now().Add(-config.MagicLinkWindow))
+	if err != nil {
+		return err
+	}
+	if count >= int64(config.MagicLinkMaxPerEmail) {
+		return nil
+	}
+
+	now := time.Now()
+	token := models.MagicLinkToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		IP:        ip,
+		ExpiresAt: now.Add(config.MagicLinkTTL),
+	}
+	tokenString, err := signToken(jwt.RegisteredClaims{
+		Issuer:    config.JWTIssuer,
+		Subject:   user.ID.String(),
+		Audience:  jwt.ClaimStrings{magicLinkAudience},
+		ExpiresAt: jwt.NewNumericDate(token.ExpiresAt),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ID:        token.ID.String(),
+	})
+	if err != nil {
+		return err
+	}
+	if err := repositories.CreateMagicLinkToken(&token); err != nil {
+		return err
+	}
+
+	sendMail(mailer.Message{
+		To:      user.Email,
+		Subject: "Вход в iPromise",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\nЧтобы войти без пароля, перейдите по ссылке:\n%s\n\n"+
+			"Ссылка действует %d минут и может быть использована один раз.\n"+
+			"Если вы не запрашивали вход, просто проигнорируйте это письмо.",
+			user.Username, appLink("/magic-link?token="+url.QueryEscape(tokenString)), int(config.MagicLinkTTL.Minutes())),
+	})
+	return nil
+}
+
+// CompleteMagicLinkLogin – проверяет подпись ссылки и гасит её. Неверные ссылки учитываются
+// в ограничении по IP так же, как неверные пароли.
+func CompleteMagicLinkLogin(tokenString, ip string) (*models.User, error) {
+	claims := &jwt.RegisteredClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, lookupVerificationKey,
+		jwt.WithValidMethods(verificationMethods()),
+		jwt.WithIssuer(config.JWTIssuer),
+		jwt.WithAudience(magicLinkAudience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil || !token.Valid {
+		RegisterLoginFailure(nil, "", ip, models.LoginFailureBadMagicLink)
+		return nil, ErrInvalidMagicLink
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, ErrInvalidMagicLink
+	}
+	linkID, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return nil, ErrInvalidMagicLink
+	}
+
+	user, err := GetUserByID(userID)
+	if err != nil {
+		return nil, ErrInvalidMagicLink
+	}
+
+	consumed, err := repositories.ConsumeMagicLinkToken(linkID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		RegisterLoginFailure(user, user.Email, ip, models.LoginFailureBadMagicLink)
+		return nil, ErrInvalidMagicLink
+	}
+	return user, nil
+}
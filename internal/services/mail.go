@@ -0,0 +1,32 @@
+package services
+
+import (
+	"log"
+
+	"github.com/raxaris/ipromise-backend/config"
+	"github.com/raxaris/ipromise-backend/internal/mailer"
+)
+
+// По умолчанию письма пишутся в лог; в main подставляется Mailer из конфигурации
+var mailSender mailer.Mailer = &mailer.LogMailer{}
+
+// SetMailer – задаёт способ отправки писем
+func SetMailer(m mailer.Mailer) {
+	mailSender = m
+}
+
+// sendMail – отправляет письмо в фоне; ошибка только логируется. Ни результат, ни время отправки
+// не влияют на ответ, поэтому он не выдаёт наличие аккаунта.
+func sendMail(msg mailer.Message) {
+	sender := mailSender
+	go func() {
+		if err := sender.Send(msg); err != nil {
+			log.Println("❌ Ошибка отправки письма:", err)
+		}
+	}()
+}
+
+// appLink – абсолютная ссылка на страницу приложения
+func appLink(path string) string {
+	return config.AppBaseURL + path
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"github.com/raxaris/ipromise-backend/internal/repositories"
+)
+
+// Ошибки
+var (
+	ErrMailIPThrottled = errors.New("слишком много запросов писем с вашего адреса, попробуйте позже")
+)
+
+// MailThrottledError – исчерпан лимит писем; RetryAfter – через сколько можно повторить
+type MailThrottledError struct {
+	Reason     error
+	RetryAfter time.Duration
+}
+
+func (e *MailThrottledError) Error() string { return e.Reason.Error() }
+func (e *MailThrottledError) Unwrap() error { return e.Reason }
+
+// checkMailRequestIP – проверяет лимит limit запросов письма вида kind с IP за окно window и записывает запрос.
+// Считаются все запросы, в том числе на незарегистрированные адреса, иначе с одного IP можно перебирать email без ограничений.
+// Отклонённые запросы не записываются, чтобы окно закрывалось.
+func checkMailRequestIP(kind, ip string, window time.Duration, limit int) error {
+	since := time.Now().Add(-window)
+	count, err := repositories.CountMailRequestsByIP(kind, ip, since)
+	if err != nil {
+		return err
+	}
+	if count >= int64(limit) {
+		return &MailThrottledError{Reason: ErrMailIPThrottled, RetryAfter: window}
+	}
+
+	if err := repositories.PurgeMailRequests(kind, since); err != nil {
+		log.Println("❌ Ошибка очистки журнала запросов писем:", err)
+	}
+	return repositories.CreateMailRequest(&models.MailRequest{ID: uuid.New(), Kind: kind, IP: ip})
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/config"
+	"github.com/raxaris/ipromise-backend/internal/dto"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"github.com/raxaris/ipromise-backend/internal/repositories"
+	"github.com/raxaris/ipromise-backend/internal/totp"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// Ошибки
+var (
+	ErrTOTPAlreadyEnabled = errors.New("двухфакторная аутентификация уже включена")
+	ErrTOTPNotEnabled     = errors.New("двухфакторная аутентификация не включена")
+	ErrTOTPNotSetUp       = errors.New("сначала начните настройку двухфакторной аутентификации")
+	ErrTOTPRequired       = errors.New("для вашей роли двухфакторная аутентификация обязательна")
+	ErrInvalidMFACode     = errors.New("неверный код подтверждения")
+	ErrInvalidMFAToken    = errors.New("недействительный или истёкший MFA-токен")
+)
+
+const (
+	mfaChallengeTTL   = 5 * time.Minute
+	mfaAudience       = "ipromise-mfa" // Отличается от aud Access-токена, чтобы challenge нельзя было им подменить
+	recoveryCodeCount = 10
+)
+
+// IsMFARequired – требуется ли роли вход через 2FA (MFA_REQUIRED_ROLES)
+func IsMFARequired(role string) bool {
+	return slices.Contains(config.MFARequiredRoles, role)
+}
+
+// SetupTOTP – создаёт новый секрет; 2FA включится после подтверждения кодом
+func SetupTOTP(userID uuid.UUID) (*dto.TOTPSetupResponse, error) {
+	user, err := GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsTOTPEnabled() {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := repositories.SetPendingTOTPSecret(userID, secret); err != nil {
+		return nil, err
+	}
+
+	uri := totp.URI(config.MFAIssuer, user.Email, secret)
+	png, err := qrcode.Encode(uri, qrcode.Medium, 256)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.TOTPSetupResponse{
+		Secret:     secret,
+		OTPAuthURI: uri,
+		QRCodePNG:  base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+// ConfirmTOTP – проверяет первый код, включает 2FA и выдаёт коды восстановления
+func ConfirmTOTP(userID uuid.UUID, code string) ([]string, error) {
+	user, err := GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsTOTPEnabled() {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTOTPNotSetUp
+	}
+
+	step, ok := totp.Validate(user.TOTPSecret, code, time.Now(), user.TOTPLastStep)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := repositories.EnableTOTP(userID, step, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTOTP – выключает 2FA после проверки кода
+func DisableTOTP(userID uuid.UUID, code string) error {
+	user, err := GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if !user.IsTOTPEnabled() {
+		return ErrTOTPNotEnabled
+	}
+	if IsMFARequired(user.Role) {
+		return ErrTOTPRequired
+	}
+	if err := VerifySecondFactor(user, code); err != nil {
+		return err
+	}
+	return repositories.DisableTOTP(userID)
+}
+
+// RegenerateRecoveryCodes – выдаёт новый набор кодов восстановления, старые перестают действовать
+func RegenerateRecoveryCodes(userID uuid.UUID, code string) ([]string, error) {
+	user, err := GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsTOTPEnabled() {
+		return nil, ErrTOTPNotEnabled
+	}
+	if err := VerifySecondFactor(user, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := repositories.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// VerifySecondFactor – принимает код TOTP или одноразовый код восстановления
+func VerifySecondFactor(user *models.User, code string) error {
+	if step, ok := totp.Validate(user.TOTPSecret, code, time.Now(), user.TOTPLastStep); ok {
+		advanced, err := repositories.AdvanceTOTPStep(user.ID, step)
+		if err != nil {
+			return err
+		}
+		if !advanced {
+			return ErrInvalidMFACode // Код уже использован параллельным запросом
+		}
+		return nil
+	}
+
+	consumed, err := repositories.ConsumeRecoveryCode(user.ID, hashOpaqueToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+// mfaChallengeClaims – MFA-токен помнит, каким способом пройден первый фактор
+type mfaChallengeClaims struct {
+	FirstFactor string `json:"ff,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// GenerateMFAChallenge – короткоживущий токен между первым фактором (пароль, внешний провайдер) и вводом кода
+func GenerateMFAChallenge(user *models.User, firstFactor string) (string, error) {
+	now := time.Now()
+	claims := mfaChallengeClaims{
+		FirstFactor: firstFactor,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    config.JWTIssuer,
+			Subject:   user.ID.String(),
+			Audience:  jwt.ClaimStrings{mfaAudience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(mfaChallengeTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        uuid.NewString(),
+		},
+	}
+	return signToken(claims)
+}
+
+// CompleteMFALogin – второй шаг входа: проверяет MFA-токен и код.
+// Неверные коды учитываются в счётчике неудачных входов так же, как неверные пароли.
+// Возвращает пользователя и способы аутентификации для `amr`.
+func CompleteMFALogin(mfaToken, code, ip string) (*models.User, []string, error) {
+	claims := &mfaChallengeClaims{}
+	token, err := jwt.ParseWithClaims(mfaToken, claims, lookupVerificationKey,
+		jwt.WithValidMethods(verificationMethods()),
+		jwt.WithIssuer(config.JWTIssuer),
+		jwt.WithAudience(mfaAudience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil || !token.Valid {
+		return nil, nil, ErrInvalidMFAToken
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, nil, ErrInvalidMFAToken
+	}
+
+	user, err := GetUserByID(userID)
+	if err != nil || !user.IsTOTPEnabled() {
+		return nil, nil, ErrInvalidMFAToken
+	}
+
+	if err := CheckAccountAllowed(user); err != nil {
+		RegisterLoginFailure(user, user.Email, ip, models.LoginFailureLocked)
+		return nil, nil, err
+	}
+
+	if err := VerifySecondFactor(user, code); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			RegisterLoginFailure(user, user.Email, ip, models.LoginFailureBadMFACode)
+		}
+		return nil, nil, err
+	}
+
+	RegisterLoginSuccess(user, ip)
+
+	firstFactor := claims.FirstFactor
+	if firstFactor == "" {
+		firstFactor = AuthMethodPassword
+	}
+	return user, []string{firstFactor, AuthMethodOTP}, nil
+}
+
+// generateRecoveryCodes – коды вида xxxxx-xxxxx и их хеши для хранения
+func generateRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		buf := make([]byte, 7)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(encoding.EncodeToString(buf))[:10]
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, hashOpaqueToken(raw))
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode – допускает ввод в любом регистре, с дефисом и пробелами
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
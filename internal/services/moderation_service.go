@@ -0,0 +1,85 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"github.com/raxaris/ipromise-backend/internal/repositories"
+)
+
+// Ошибки
+var (
+	ErrPromiseAlreadyHidden = errors.New("обещание уже скрыто")
+	ErrPromiseNotHidden     = errors.New("обещание не скрыто")
+)
+
+const maxModerationPage = 200
+
+// getPublicPromise – модерируется только публичный контент; приватные обещания для модератора не существуют
+func getPublicPromise(promiseID uuid.UUID) (*models.Promise, error) {
+	promise, err := repositories.GetPromiseByID(promiseID)
+	if err != nil || promise.IsPrivate {
+		return nil, ErrPromiseNotFound
+	}
+	return promise, nil
+}
+
+// GetRecentPublicPromises – лента свежих публичных обещаний для модерации
+func GetRecentPublicPromises(since *time.Time, limit int) ([]models.Promise, error) {
+	if limit <= 0 || limit > maxModerationPage {
+		limit = maxModerationPage
+	}
+	return repositories.GetRecentPublicPromises(since, limit)
+}
+
+// HidePromise – скрывает публичное обещание из общих списков; автор продолжает его видеть
+func HidePromise(moderatorID, promiseID uuid.UUID, reason string) error {
+	if _, err := getPublicPromise(promiseID); err != nil {
+		return err
+	}
+
+	hidden, err := repositories.SetPromiseHidden(promiseID, &moderatorID, strings.TrimSpace(reason))
+	if err != nil {
+		return err
+	}
+	if !hidden {
+		return ErrPromiseAlreadyHidden
+	}
+	return nil
+}
+
+// UnhidePromise – возвращает обещание в публичные списки
+func UnhidePromise(promiseID uuid.UUID) error {
+	if _, err := getPublicPromise(promiseID); err != nil {
+		return err
+	}
+
+	unhidden, err := repositories.SetPromiseHidden(promiseID, nil, "")
+	if err != nil {
+		return err
+	}
+	if !unhidden {
+		return ErrPromiseNotHidden
+	}
+	return nil
+}
+
+// ModeratePromiseTitle – заменяет недопустимый заголовок публичного обещания; возвращает изменение для журнала аудита
+func ModeratePromiseTitle(promiseID uuid.UUID, title string) (models.AuditChanges, error) {
+	title, err := normalizePromiseTitle(title)
+	if err != nil {
+		return nil, err
+	}
+
+	promise, err := getPublicPromise(promiseID)
+	if err != nil {
+		return nil, err
+	}
+	if err := repositories.UpdatePromiseTitle(promiseID, title); err != nil {
+		return nil, err
+	}
+	return models.AuditChanges{"title": {From: promise.Title, To: title}}, nil
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/config"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"github.com/raxaris/ipromise-backend/internal/oidc"
+	"github.com/raxaris/ipromise-backend/internal/repositories"
+	"gorm.io/gorm"
+)
+
+// Ошибки
+var (
+	ErrOIDCProviderNotFound = errors.New("неизвестный OIDC-провайдер")
+	ErrOIDCInvalidState     = errors.New("сессия входа через внешний аккаунт устарела, начните заново")
+	ErrOIDCEmailRequired    = errors.New("провайдер не передал email, войти через него нельзя")
+	ErrOIDCEmailConflict    = errors.New("аккаунт с этим email уже существует; войдите с паролем – привязать вход можно, когда email подтверждён и провайдером, и в аккаунте")
+)
+
+// AuthMethodOIDC – вход через внешнего OIDC-провайдера (значение `amr`)
+const AuthMethodOIDC = "fed"
+
+// OIDCStateTTL – сколько действует начатый вход через провайдера
+const OIDCStateTTL = 10 * time.Minute
+
+var (
+	oidcProviders  = map[string]*oidc.Provider{}
+	usernameFilter = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+)
+
+// LoadOIDCProviders – создаёт провайдеров из конфигурации
+func LoadOIDCProviders() {
+	oidcProviders = map[string]*oidc.Provider{}
+	for _, p := range config.OIDCProviders {
+		oidcProviders[p.Name] = oidc.NewProvider(p.Name, p.Issuer, p.ClientID, p.ClientSecret, p.RedirectURL, p.Scopes)
+	}
+}
+
+// GetOIDCProviderNames – настроенные провайдеры
+func GetOIDCProviderNames() []string {
+	names := make([]string, 0, len(config.OIDCProviders))
+	for _, p := range config.OIDCProviders {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// StartOIDCLogin – создаёт state, nonce и PKCE verifier и возвращает адрес входа у провайдера и state,
+// который нужно сохранить в cookie браузера: callback принимается только вместе с ней
+func StartOIDCLogin(ctx context.Context, providerName string) (string, string, error) {
+	provider, ok := oidcProviders[providerName]
+	if !ok {
+		return "", "", ErrOIDCProviderNotFound
+	}
+
+	state, err := generateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := generateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := generateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return "", "", err
+	}
+
+	err = repositories.CreateOIDCAuthState(&models.OIDCAuthState{
+		ID:           uuid.New(),
+		StateHash:    hashOpaqueToken(state),
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(OIDCStateTTL),
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return authURL, state, nil
+}
+
+// CompleteOIDCLogin – обменивает код, проверяет ID-токен и находит, привязывает или создаёт пользователя.
+// cookieState – state из cookie браузера, начавшего вход: без совпадения чужую ссылку callback не подсунуть (login CSRF).
+func CompleteOIDCLogin(ctx context.Context, providerName, state, cookieState, code string) (*models.User, error) {
+	provider, ok := oidcProviders[providerName]
+	if !ok {
+		return nil, ErrOIDCProviderNotFound
+	}
+	if cookieState == "" || subtle.ConstantTimeCompare([]byte(state), []byte(cookieState)) != 1 {
+		return nil, ErrOIDCInvalidState
+	}
+
+	authState, err := repositories.ConsumeOIDCAuthState(hashOpaqueToken(state), providerName)
+	if err != nil {
+		return nil, ErrOIDCInvalidState
+	}
+
+	claims, err := provider.Exchange(ctx, code, authState.CodeVerifier, authState.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return findOrCreateOIDCUser(providerName, claims)
+}
+
+// findOrCreateOIDCUser – вход по привязке; иначе привязка по подтверждённому email; иначе новый пользователь
+func findOrCreateOIDCUser(providerName string, claims *oidc.Claims) (*models.User, error) {
+	identity, err := repositories.GetUserIdentity(providerName, claims.Subject)
+	if err == nil {
+		return GetUserByID(identity.UserID)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	email := strings.TrimSpace(claims.Email)
+	if email == "" {
+		return nil, ErrOIDCEmailRequired
+	}
+
+	newIdentity := models.UserIdentity{
+		ID:       uuid.New(),
+		Provider: providerName,
+		Subject:  claims.Subject,
+		Email:    email,
+	}
+
+	existing, err := repositories.GetUserByEmail(email)
+	if err == nil {
+		if err := checkOIDCLink(claims, existing); err != nil {
+			return nil, err
+		}
+		newIdentity.UserID = existing.ID
+		if err := repositories.CreateUserIdentity(&newIdentity); err != nil {
+			return nil, err
+		}
+		return existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	username, err := uniqueUsername(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	// Пароля у такого пользователя нет: случайное значение, задать свой можно через сброс пароля
+	randomPassword, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	user := &models.User{
+		ID:       uuid.New(),
+		Username: username,
+		Email:    email,
+		Password: randomPassword,
+	}
+	if claims.EmailVerified {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+	if err := user.HashPassword(); err != nil {
+		return nil, err
+	}
+
+	if err := repositories.CreateUserWithIdentity(user, &newIdentity); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// checkOIDCLink – можно ли привязать вход через провайдера к существующему аккаунту с тем же email.
+// Только если адрес подтверждён и провайдером, и в аккаунте: иначе аккаунт мог заранее зарегистрировать
+// кто-то другой со своим паролем.
+func checkOIDCLink(claims *oidc.Claims, existing *models.User) error {
+	if !claims.EmailVerified || !existing.IsEmailVerified() {
+		return ErrOIDCEmailConflict
+	}
+	return nil
+}
+
+// uniqueUsername – имя пользователя из preferred_username или email с суффиксом при совпадении
+func uniqueUsername(claims *oidc.Claims) (string, error) {
+	base := claims.PreferredUsername
+	if base == "" {
+		base, _, _ = strings.Cut(claims.Email, "@")
+	}
+	base = usernameFilter.ReplaceAllString(base, "")
+	if len(base) > 30 {
+		base = base[:30]
+	}
+	for len(base) < 3 {
+		base += "_"
+	}
+
+	if !repositories.IsUsernameExists(base) {
+		return base, nil
+	}
+	for i := 0; i < 5; i++ {
+		candidate := fmt.Sprintf("%s_%s", base, uuid.NewString()[:6])
+		if !repositories.IsUsernameExists(candidate) {
+			return candidate, nil
+		}
+	}
+	return "", ErrUsernameTaken
+}
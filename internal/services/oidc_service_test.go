@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"github.com/raxaris/ipromise-backend/internal/oidc"
+)
+
+func TestCompleteOIDCLoginRequiresStateCookie(t *testing.T) {
+	saved := oidcProviders
+	t.Cleanup(func() { oidcProviders = saved })
+	// Провайдер недоступен: проверка cookie должна отклонить вход раньше обращений к нему и к базе
+	oidcProviders = map[string]*oidc.Provider{
+		"mock": oidc.NewProvider("mock", "http://127.0.0.1:0", "client", "", "http://localhost/callback", nil),
+	}
+
+	tests := []struct {
+		name        string
+		cookieState string
+	}{
+		{"no cookie", ""},
+		{"other browser", "state-of-another-login"},
+		{"prefix", "state-"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CompleteOIDCLogin(context.Background(), "mock", "state-1", tt.cookieState, "code")
+			if !errors.Is(err, ErrOIDCInvalidState) {
+				t.Fatalf("err = %v, want ErrOIDCInvalidState", err)
+			}
+		})
+	}
+
+	if _, err := CompleteOIDCLogin(context.Background(), "unknown", "state-1", "state-1", "code"); !errors.Is(err, ErrOIDCProviderNotFound) {
+		t.Fatalf("err = %v, want ErrOIDCProviderNotFound", err)
+	}
+}
+
+func TestCheckOIDCLink(t *testing.T) {
+	verifiedAt := time.Now()
+
+	tests := []struct {
+		name             string
+		providerVerified bool
+		accountVerified  *time.Time
+		wantErr          error
+	}{
+		{"both verified", true, &verifiedAt, nil},
+		{"unverified by provider", false, &verifiedAt, ErrOIDCEmailConflict},
+		{"unverified account", true, nil, ErrOIDCEmailConflict},
+		{"unverified anywhere", false, nil, ErrOIDCEmailConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := &oidc.Claims{Subject: "subject-1", Email: "user@example.com", EmailVerified: tt.providerVerified}
+			existing := &models.User{Email: "user@example.com", EmailVerifiedAt: tt.accountVerified}
+
+			if err := checkOIDCLink(claims, existing); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
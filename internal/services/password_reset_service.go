@@ -0,0 +1,108 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/config"
+	"github.com/raxaris/ipromise-backend/internal/mailer"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"github.com/raxaris/ipromise-backend/internal/repositories"
+)
+
+// Ошибки
+var (
+	ErrInvalidResetToken = errors.New("ссылка для сброса пароля недействительна или устарела")
+)
+
+const passwordResetTTL = time.Hour
+
+// RequestPasswordReset – отправляет письмо со ссылкой сброса, если email зарегистрирован.
+// Для незарегистрированного email и сверх лимита на email ничего не происходит – ответ одинаковый,
+// а письмо уходит в фоне, чтобы наличие аккаунта не выдавало и время ответа. Лимит на IP возвращает MailThrottledError.
+func RequestPasswordReset(email, ip string) error {
+	if err := checkMailRequestIP(models.MailRequestPasswordReset, ip, config.PasswordResetWindow, config.PasswordResetMaxPerIP); err != nil {
+		return err
+	}
+
+	user, err := repositories.GetUserByEmail(strings.TrimSpace(email))
+	if err != nil {
+		return nil
+	}
+
+	count, err := repositories.CountPasswordResetsByUser(user.ID, time.Now().Add(-config.PasswordResetWindow))
+	if err != nil {
+		return err
+	}
+	if count >= int64(config.PasswordResetMaxPerEmail) {
+		return nil
+	}
+
+	tokenString, err := generateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	token := models.PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		IP:        ip,
+		TokenHash: hashOpaqueToken(tokenString),
+		ExpiresAt: time.Now().Add(passwordResetTTL),
+	}
+	if err := repositories.CreatePasswordResetToken(&token); err != nil {
+		return err
+	}
+
+	sendMail(mailer.Message{
+		To:      user.Email,
+		Subject: "Сброс пароля iPromise",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\nЧтобы задать новый пароль, перейдите по ссылке:\n%s\n\n"+
+			"Ссылка действует %d минут и может быть использована один раз.\n"+
+			"Если вы не запрашивали сброс, просто проигнорируйте это письмо.",
+			user.Username, appLink("/reset-password?token="+url.QueryEscape(tokenString)), int(passwordResetTTL.Minutes())),
+	})
+	return nil
+}
+
+// ResetPassword – задаёт новый пароль по токену и завершает все сессии пользователя; возвращает его ID
+func ResetPassword(tokenString, newPassword string) (uuid.UUID, error) {
+	token, err := repositories.GetPasswordResetTokenByHash(hashOpaqueToken(tokenString))
+	if err != nil || token.UsedAt != nil || time.Now().After(token.ExpiresAt) {
+		return uuid.Nil, ErrInvalidResetToken
+	}
+
+	owner, err := GetUserByID(token.UserID)
+	if err != nil {
+		return uuid.Nil, ErrInvalidResetToken
+	}
+	if err := ValidatePassword(newPassword, owner.Username, owner.Email); err != nil {
+		return uuid.Nil, err
+	}
+
+	user := models.User{Password: newPassword}
+	if err := user.HashPassword(); err != nil {
+		return uuid.Nil, err
+	}
+
+	consumed, err := repositories.ConsumePasswordResetToken(token.ID, token.UserID, user.Password)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if !consumed {
+		return uuid.Nil, ErrInvalidResetToken
+	}
+
+	// Пароль сменился – старые токены, персональные токены и сессии больше не действуют
+	if err := BumpTokenVersion(token.UserID); err != nil {
+		return uuid.Nil, err
+	}
+	if err := repositories.RevokeAllPersonalTokens(token.UserID); err != nil {
+		return uuid.Nil, err
+	}
+	return token.UserID, RevokeAllSessions(token.UserID)
+}
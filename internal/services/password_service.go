@@ -0,0 +1,233 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/config"
+	"github.com/raxaris/ipromise-backend/internal/breach"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"github.com/raxaris/ipromise-backend/internal/password"
+	"github.com/raxaris/ipromise-backend/internal/repositories"
+)
+
+// Коды нарушений политики паролей
+const (
+	PasswordTooShort    = "too_short"
+	PasswordTooLong     = "too_long"
+	PasswordNoLowercase = "no_lowercase"
+	PasswordNoUppercase = "no_uppercase"
+	PasswordNoDigit     = "no_digit"
+	PasswordNoSymbol    = "no_symbol"
+	PasswordPersonal    = "contains_personal_info"
+	PasswordBreached    = "breached"
+)
+
+// Ошибки
+var (
+	ErrInvalidCurrentPassword = errors.New("неверный текущий пароль")
+	ErrSamePassword           = errors.New("новый пароль совпадает с текущим")
+)
+
+// bcrypt учитывает только первые 72 байта пароля
+const bcryptMaxBytes = 72
+
+// PasswordViolation – одно нарушение политики паролей
+type PasswordViolation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicyError – пароль не прошёл политику; содержит все нарушения сразу
+type PasswordPolicyError struct {
+	Violations []PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Message
+	}
+	return "пароль не соответствует требованиям: " + strings.Join(messages, "; ")
+}
+
+var breachChecker breach.Checker
+
+// LoadPasswordPolicy – применяет параметры хеширования паролей из конфигурации
+func LoadPasswordPolicy() {
+	password.SetCurrent(password.Params{
+		Algorithm:     config.PasswordHashAlgorithm,
+		Argon2Memory:  config.PasswordArgon2Memory,
+		Argon2Time:    config.PasswordArgon2Time,
+		Argon2Threads: config.PasswordArgon2Threads,
+		BcryptCost:    config.PasswordBcryptCost,
+	})
+
+	breachChecker = nil
+	if config.PasswordBreachedFile != "" {
+		breachChecker = &breach.FileChecker{Path: config.PasswordBreachedFile}
+	}
+}
+
+// ValidatePassword – проверяет новый пароль по политике (PASSWORD_*) и базе утечек.
+// username и email – данные владельца, которые не должны входить в пароль.
+func ValidatePassword(plain, username, email string) error {
+	var violations []PasswordViolation
+	add := func(code, message string) {
+		violations = append(violations, PasswordViolation{Code: code, Message: message})
+	}
+
+	length := utf8.RuneCountInString(plain)
+	if length < config.PasswordMinLength {
+		add(PasswordTooShort, fmt.Sprintf("пароль должен содержать минимум %d символов", config.PasswordMinLength))
+	}
+	if length > config.PasswordMaxLength || (config.PasswordHashAlgorithm == password.Bcrypt && len(plain) > bcryptMaxBytes) {
+		add(PasswordTooLong, "пароль слишком длинный")
+	}
+
+	var hasLower, hasUpper, hasDigit, hasSymbol bool
+	for _, r := range plain {
+		switch {
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if config.PasswordRequireLower && !hasLower {
+		add(PasswordNoLowercase, "пароль должен содержать строчную букву")
+	}
+	if config.PasswordRequireUpper && !hasUpper {
+		add(PasswordNoUppercase, "пароль должен содержать заглавную букву")
+	}
+	if config.PasswordRequireDigit && !hasDigit {
+		add(PasswordNoDigit, "пароль должен содержать цифру")
+	}
+	if config.PasswordRequireSymbol && !hasSymbol {
+		add(PasswordNoSymbol, "пароль должен содержать спецсимвол")
+	}
+
+	if config.PasswordRejectPersonal && containsPersonalInfo(plain, username, email) {
+		add(PasswordPersonal, "пароль не должен содержать имя пользователя или email")
+	}
+
+	if breachChecker != nil {
+		count, err := breachChecker.Count(plain)
+		if err != nil {
+			// База утечек недоступна – не блокируем смену пароля, но сообщаем в лог
+			log.Println("❌ Ошибка проверки пароля по базе утечек:", err)
+		} else if count >= config.PasswordBreachedMinCount {
+			add(PasswordBreached, "пароль встречается в известных утечках, выберите другой")
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// containsPersonalInfo – входит ли в пароль имя пользователя или локальная часть email (без учёта регистра)
+func containsPersonalInfo(plain, username, email string) bool {
+	lower := strings.ToLower(plain)
+	local, _, _ := strings.Cut(email, "@")
+	for _, part := range []string{username, local} {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if utf8.RuneCountInString(part) >= 3 && strings.Contains(lower, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// RehashPasswordIfNeeded – после успешного входа пересчитывает хеш, созданный по устаревшей политике.
+// Хеш заменяется, только если его не успели сменить параллельно (например, сбросом пароля).
+func RehashPasswordIfNeeded(user *models.User, plain string) error {
+	if !user.NeedsRehash() {
+		return nil
+	}
+
+	oldHash := user.Password
+	rehashed := models.User{Password: plain}
+	if err := rehashed.HashPassword(); err != nil {
+		return err
+	}
+
+	updated, err := repositories.ReplacePasswordHash(user.ID, oldHash, rehashed.Password)
+	if err != nil {
+		return err
+	}
+	if updated {
+		user.Password = rehashed.Password
+	}
+	return nil
+}
+
+// ChangePassword – меняет пароль после проверки текущего. Все Access-токены и сессии пользователя
+// отзываются, текущему клиенту выдаётся новая пара токенов с теми же способами входа (info.AuthMethods).
+func ChangePassword(userID uuid.UUID, currentPassword, newPassword string, info SessionInfo) (string, string, error) {
+	user, err := GetUserByID(userID)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Заблокированный аккаунт не проверяет пароль – иначе смена пароля стала бы обходом блокировки перебора
+	if err := CheckAccountAllowed(user); err != nil {
+		return "", "", err
+	}
+	if !user.CheckPassword(currentPassword) {
+		RegisterLoginFailure(user, user.Email, info.IP, models.LoginFailureBadPassword)
+		return "", "", ErrInvalidCurrentPassword
+	}
+	if currentPassword == newPassword {
+		return "", "", ErrSamePassword
+	}
+	if err := ValidatePassword(newPassword, user.Username, user.Email); err != nil {
+		return "", "", err
+	}
+
+	oldHash := user.Password
+	user.Password = newPassword
+	if err := user.HashPassword(); err != nil {
+		return "", "", err
+	}
+	updated, err := repositories.ReplacePasswordHash(user.ID, oldHash, user.Password)
+	if err != nil {
+		return "", "", err
+	}
+	if !updated {
+		// Пароль успели сменить параллельно (например, сбросом)
+		return "", "", ErrInvalidCurrentPassword
+	}
+
+	if err := BumpTokenVersion(user.ID); err != nil {
+		return "", "", err
+	}
+	if err := RevokeAllSessions(user.ID); err != nil {
+		return "", "", err
+	}
+	// Персональные токены не зависят от версии токенов – отзываем их явно
+	if err := repositories.RevokeAllPersonalTokens(user.ID); err != nil {
+		return "", "", err
+	}
+
+	// Перечитываем пользователя, чтобы новый Access-токен получил актуальную версию
+	user, err = GetUserByID(user.ID)
+	if err != nil {
+		return "", "", err
+	}
+	// Новая сессия продолжает текущую: способы входа (в том числе otp) сохраняются
+	if len(info.AuthMethods) == 0 {
+		info.AuthMethods = []string{AuthMethodPassword}
+	}
+	return IssueTokenPair(user, info)
+}
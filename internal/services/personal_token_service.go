@@ -0,0 +1,143 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/internal/dto"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"github.com/raxaris/ipromise-backend/internal/repositories"
+)
+
+// Scope персональных токенов
+const (
+	ScopePromisesRead  = "promises:read"
+	ScopePromisesWrite = "promises:write"
+)
+
+// PersonalTokenPrefix – по префиксу AuthMiddleware отличает персональный токен от JWT
+const PersonalTokenPrefix = "ipat_"
+
+// Ошибки
+var (
+	ErrInvalidScope         = errors.New("неизвестный scope")
+	ErrInvalidTokenExpiry   = errors.New("срок действия токена должен быть в будущем")
+	ErrPersonalTokenInvalid = errors.New("недействительный персональный токен")
+	ErrPersonalTokenMissing = errors.New("персональный токен не найден")
+)
+
+var knownScopes = []string{ScopePromisesRead, ScopePromisesWrite}
+
+// Время последнего использования пишется не чаще раза в минуту, чтобы не нагружать БД
+const personalTokenTouchInterval = time.Minute
+
+// CreatePersonalToken – создаёт токен; значение возвращается один раз и больше нигде не хранится
+func CreatePersonalToken(userID uuid.UUID, req dto.CreatePersonalTokenRequest) (*dto.CreatedPersonalTokenResponse, error) {
+	scopes := make([]string, 0, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		scope = strings.TrimSpace(scope)
+		if !slices.Contains(knownScopes, scope) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidScope, scope)
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidTokenExpiry
+	}
+
+	secret, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	tokenString := PersonalTokenPrefix + secret
+
+	token := models.PersonalAccessToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      strings.TrimSpace(req.Name),
+		TokenHash: hashOpaqueToken(tokenString),
+		Prefix:    tokenString[:len(PersonalTokenPrefix)+6],
+		Scopes:    strings.Join(scopes, ","),
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := repositories.CreatePersonalToken(&token); err != nil {
+		return nil, err
+	}
+
+	return &dto.CreatedPersonalTokenResponse{
+		PersonalTokenResponse: toPersonalTokenResponse(&token),
+		Token:                 tokenString,
+	}, nil
+}
+
+// GetPersonalTokens – неотозванные токены пользователя
+func GetPersonalTokens(userID uuid.UUID) ([]dto.PersonalTokenResponse, error) {
+	tokens, err := repositories.GetPersonalTokensByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]dto.PersonalTokenResponse, 0, len(tokens))
+	for i := range tokens {
+		response = append(response, toPersonalTokenResponse(&tokens[i]))
+	}
+	return response, nil
+}
+
+// RevokePersonalToken – отзывает токен пользователя
+func RevokePersonalToken(userID, tokenID uuid.UUID) error {
+	affected, err := repositories.RevokePersonalToken(userID, tokenID)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrPersonalTokenMissing
+	}
+	return nil
+}
+
+// AuthenticatePersonalToken – проверяет персональный токен и возвращает его владельца
+func AuthenticatePersonalToken(tokenString string) (*models.PersonalAccessToken, *models.User, error) {
+	token, err := repositories.GetPersonalTokenByHash(hashOpaqueToken(tokenString))
+	if err != nil {
+		return nil, nil, ErrPersonalTokenInvalid
+	}
+
+	now := time.Now()
+	if !token.IsActive(now) {
+		return nil, nil, ErrPersonalTokenInvalid
+	}
+
+	user, err := GetUserByID(token.UserID)
+	if err != nil {
+		return nil, nil, ErrPersonalTokenInvalid
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > personalTokenTouchInterval {
+		if err := repositories.TouchPersonalToken(token.ID, now); err != nil {
+			log.Println("❌ Ошибка обновления персонального токена:", err)
+		}
+	}
+
+	return token, user, nil
+}
+
+func toPersonalTokenResponse(token *models.PersonalAccessToken) dto.PersonalTokenResponse {
+	return dto.PersonalTokenResponse{
+		ID:         token.ID,
+		Name:       token.Name,
+		Prefix:     token.Prefix,
+		Scopes:     token.ScopeList(),
+		CreatedAt:  token.CreatedAt,
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+	}
+}
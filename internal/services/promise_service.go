@@ -3,30 +3,62 @@ package services
 import (
 	"errors"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/internal/authz"
 	"github.com/raxaris/ipromise-backend/internal/dto"
 	"github.com/raxaris/ipromise-backend/internal/models"
+	"github.com/raxaris/ipromise-backend/internal/pagination"
 	"github.com/raxaris/ipromise-backend/internal/repositories"
 )
 
 // Ошибки
 var (
 	ErrNotAllowedToUpdate = errors.New("вы не можете редактировать это обещание")
+	ErrNotAllowedToDelete = errors.New("у вас нет прав на удаление обещания")
 	ErrInvalidStatus      = errors.New("нельзя изменить статус на этот")
 	ErrPromiseNotFound    = errors.New("обещание не найдено")
-	ErrInvalidTitle       = errors.New("заголовок обещания не может быть пустым или короче 3 символов")
+	ErrPromisePrivate     = errors.New("это приватное обещание")
+	ErrInvalidTitle       = errors.New("заголовок обещания должен содержать от 5 до 255 символов")
+	ErrTitleLocked        = errors.New("заголовок скрытого или исправленного модератором обещания менять нельзя")
+	ErrInvalidSearchQuery = errors.New("поисковый запрос должен содержать от 1 до 200 символов")
 )
 
+// Максимальная длина поискового запроса
+const maxSearchQueryLength = 200
+
+// Допустимая длина заголовка обещания
+const (
+	minPromiseTitleLength = 5
+	maxPromiseTitleLength = 255 // Размер колонки title
+)
+
+// normalizePromiseTitle – заголовок без пробелов по краям; ErrInvalidTitle, если он слишком короткий или длинный
+func normalizePromiseTitle(title string) (string, error) {
+	title = strings.TrimSpace(title)
+	length := utf8.RuneCountInString(title)
+	if length < minPromiseTitleLength || length > maxPromiseTitleLength {
+		return "", ErrInvalidTitle
+	}
+	return title, nil
+}
+
 // CreatePromise – создание нового обещания (юзер/админ)
 func CreatePromise(userID uuid.UUID, req dto.CreatePromiseRequest) error {
-	// Убираем пробелы в заголовке и описании
-	req.Title = strings.TrimSpace(req.Title)
+	// Убираем пробелы в заголовке и описании и проверяем заголовок
+	title, err := normalizePromiseTitle(req.Title)
+	if err != nil {
+		return err
+	}
+	req.Title = title
 	req.Description = strings.TrimSpace(req.Description)
 
-	// Проверяем корректность заголовка
-	if len(req.Title) < 5 {
-		return ErrInvalidTitle
+	// Публичные обещания могут требовать подтверждённого email
+	if !req.IsPrivate {
+		if err := ensureCanPublish(userID); err != nil {
+			return err
+		}
 	}
 
 	// Создаём новый объект обещания
@@ -51,8 +83,9 @@ func CreatePromise(userID uuid.UUID, req dto.CreatePromiseRequest) error {
 		}
 	} else {
 		// Если это прогресс (обновление обещания)
+		// Прогресс добавляется только к своему обещанию
 		parentPromise, err := repositories.GetPromiseByID(*req.ParentID)
-		if err != nil {
+		if err != nil || parentPromise.UserID != userID {
 			return errors.New("родительское обещание не найдено")
 		}
 
@@ -71,58 +104,160 @@ func CreatePromise(userID uuid.UUID, req dto.CreatePromiseRequest) error {
 	return repositories.CreatePromise(&promise)
 }
 
-// GetAllPublicPromises – получает только публичные обещания
-func GetAllPublicPromises() ([]models.Promise, error) {
-	return repositories.GetPublicPromises()
+// ListPromises – страница обещаний по фильтрам с курсором следующей страницы
+func ListPromises(filter dto.PromiseFilter, page pagination.Page) (pagination.Result[models.Promise], error) {
+	promises, err := repositories.ListPromises(filter, page)
+	if err != nil {
+		return pagination.Result[models.Promise]{}, err
+	}
+	return pagination.Finish(promises, page, func(p models.Promise) pagination.Cursor {
+		if page.Sort == "deadline" {
+			return pagination.Cursor{Value: p.Deadline, ID: p.ID}
+		}
+		return pagination.Cursor{Value: p.CreatedAt, ID: p.ID}
+	}), nil
 }
 
-// GetPromiseByID – получает обещание по ID (проверка приватности)
-func GetPromiseByID(promiseID uuid.UUID) (*models.Promise, error) {
-	promise, err := repositories.GetPromiseByID(promiseID)
-	if err != nil {
-		return nil, errors.New("обещание не найдено")
+// SearchPromises – полнотекстовый поиск по заголовку и описанию: страница найденного по релевантности или дате
+func SearchPromises(text string, filter dto.PromiseFilter, page pagination.Page) (pagination.Result[dto.PromiseSearchHit], error) {
+	text = strings.TrimSpace(text)
+	if text == "" || utf8.RuneCountInString(text) > maxSearchQueryLength {
+		return pagination.Result[dto.PromiseSearchHit]{}, ErrInvalidSearchQuery
 	}
 
-	// Если обещание приватное – вернуть ошибку
-	if promise.IsPrivate {
-		return nil, errors.New("обещание приватное")
+	hits, err := repositories.SearchPromises(text, filter, page)
+	if err != nil {
+		return pagination.Result[dto.PromiseSearchHit]{}, err
 	}
+	return pagination.Finish(hits, page, func(hit dto.PromiseSearchHit) pagination.Cursor {
+		if page.Sort == "rank" {
+			return pagination.Cursor{Rank: hit.Rank, ID: hit.ID}
+		}
+		return pagination.Cursor{Value: hit.CreatedAt, ID: hit.ID}
+	}), nil
+}
 
+// GetPromiseByID – получает обещание по ID с проверкой, что actor может его видеть
+func GetPromiseByID(actor authz.Actor, promiseID uuid.UUID) (*models.Promise, error) {
+	promise, err := repositories.GetPromiseByID(promiseID)
+	if err != nil {
+		return nil, ErrPromiseNotFound
+	}
+	if err := CheckPromiseVisible(actor, promise); err != nil {
+		return nil, err
+	}
 	return promise, nil
 }
 
-// GetAllPromises – получение всех обещаний
-func GetAllPromises() ([]models.Promise, error) {
-	return repositories.GetAllPromises()
+// GetPromiseTree – основное обещание, к которому относится promiseID, с деревом прогресса и сводкой.
+// Правила просмотра применяются к запрошенному и основному обещанию, а записи прогресса другого автора и те,
+// которые actor видеть не может, пропускаются вместе с вложенными и не учитываются в сводке.
+func GetPromiseTree(actor authz.Actor, promiseID uuid.UUID) (*dto.PromiseTree, error) {
+	promises, err := repositories.GetPromiseTree(promiseID)
+	if err != nil {
+		return nil, err
+	}
+	if len(promises) == 0 {
+		return nil, ErrPromiseNotFound
+	}
+
+	root := promises[0]
+	children := make(map[uuid.UUID][]models.Promise)
+	var requested *models.Promise
+	for i, promise := range promises {
+		if promise.ID == promiseID {
+			requested = &promises[i]
+		}
+		if promise.ParentID != nil {
+			children[*promise.ParentID] = append(children[*promise.ParentID], promise)
+		}
+	}
+	if requested == nil {
+		return nil, ErrPromiseNotFound
+	}
+	if err := CheckPromiseVisible(actor, requested); err != nil {
+		return nil, err
+	}
+	if err := CheckPromiseVisible(actor, &root); err != nil {
+		return nil, err
+	}
+
+	tree := &dto.PromiseTree{LatestStatus: root.Status}
+	var latest *models.Promise
+	var build func(promise models.Promise) dto.PromiseTreeNode
+	build = func(promise models.Promise) dto.PromiseTreeNode {
+		node := dto.PromiseTreeNode{Promise: promise, Progress: []dto.PromiseTreeNode{}}
+		for _, child := range children[promise.ID] {
+			// Прогресс ведёт только автор: чужие записи (созданные до проверки владельца) не показываются и не считаются
+			if child.UserID != root.UserID || CheckPromiseVisible(actor, &child) != nil {
+				continue
+			}
+			tree.ProgressCount++
+			switch child.Status {
+			case "in_progress":
+				tree.InProgressCount++
+			case "completed":
+				tree.CompletedCount++
+			}
+			if latest == nil || child.CreatedAt.After(latest.CreatedAt) {
+				latest = &child
+			}
+			node.Progress = append(node.Progress, build(child))
+		}
+		return node
+	}
+	tree.PromiseTreeNode = build(root)
+
+	if latest != nil {
+		tree.LatestStatus = latest.Status
+	}
+	switch {
+	case root.Status == "completed" || tree.LatestStatus == "completed":
+		tree.Percentage = 100
+	case tree.ProgressCount > 0:
+		tree.Percentage = tree.CompletedCount * 100 / tree.ProgressCount
+	}
+	return tree, nil
 }
 
-// GetPromiseByUserID – получение обещаний пользователя
-func GetPromiseByUserID(userID uuid.UUID) ([]models.Promise, error) {
-	return repositories.GetPromisesByUserID(userID)
+// CheckPromiseVisible – правила просмотра обещания: приватное видят владелец и роли с promise.read.any,
+// скрытое модератором и обещание приостановленного или заблокированного автора – ещё и модерация
+func CheckPromiseVisible(actor authz.Actor, promise *models.Promise) error {
+	canRead := authz.Can(actor, authz.PromiseRead, promise)
+	if promise.IsPrivate && !canRead {
+		return ErrPromisePrivate
+	}
+	if canRead || authz.Can(actor, authz.PromiseHide, nil) {
+		return nil
+	}
+	if promise.IsHidden() || CheckUserActive(promise.UserID) != nil {
+		return ErrPromiseNotFound
+	}
+	return nil
 }
 
-// UpdatePromise – обновление обещания (с учетом ролей)
-func UpdatePromise(userID uuid.UUID, promiseID string, updateData dto.UpdatePromiseRequest, isAdmin bool) error {
+// UpdatePromise – обновление обещания (с учетом разрешений actor); возвращает обещание и изменённые поля
+func UpdatePromise(actor authz.Actor, promiseID string, updateData dto.UpdatePromiseRequest) (*models.Promise, models.AuditChanges, error) {
 	// Преобразуем promiseID в UUID
 	promiseUUID, err := uuid.Parse(promiseID)
 	if err != nil {
-		return errors.New("Неверный формат ID обещания")
+		return nil, nil, errors.New("Неверный формат ID обещания")
 	}
 
 	// Получаем текущее обещание
 	existingPromise, err := repositories.GetPromiseByID(promiseUUID)
 	if err != nil {
-		return ErrPromiseNotFound
+		return nil, nil, ErrPromiseNotFound
 	}
 
 	// 1️⃣ Проверяем, имеет ли право пользователь редактировать обещание
-	if existingPromise.UserID != userID && !isAdmin {
-		return ErrNotAllowedToUpdate
+	if !authz.Can(actor, authz.PromiseUpdate, existingPromise) {
+		return nil, nil, ErrNotAllowedToUpdate
 	}
 
 	// 3️⃣ Нельзя менять `Deadline`, если это прогресс
 	if existingPromise.ParentID != nil && updateData.Deadline != nil {
-		return errors.New("Нельзя менять дедлайн у прогресса")
+		return nil, nil, errors.New("Нельзя менять дедлайн у прогресса")
 	}
 
 	// 4️⃣ Проверяем корректность изменения статуса
@@ -134,12 +269,22 @@ func UpdatePromise(userID uuid.UUID, promiseID string, updateData dto.UpdateProm
 
 	allowedNextStatuses, ok := validTransitions[existingPromise.Status]
 	if !ok || (updateData.Status != nil && !allowedNextStatuses[*updateData.Status]) {
-		return ErrInvalidStatus
+		return nil, nil, ErrInvalidStatus
 	}
 
 	// ✅ Всё в порядке – обновляем данные
+	before := *existingPromise
 	if updateData.Title != nil {
-		existingPromise.Title = *updateData.Title
+		title, err := normalizePromiseTitle(*updateData.Title)
+		if err != nil {
+			return nil, nil, err
+		}
+		// Решение модератора автор не отменяет: править такой заголовок может только модерация
+		if title != existingPromise.Title && (existingPromise.IsHidden() || existingPromise.TitleModeratedAt != nil) &&
+			!authz.Can(actor, authz.PromiseEditTitle, nil) {
+			return nil, nil, ErrTitleLocked
+		}
+		existingPromise.Title = title
 	}
 	if updateData.Description != nil {
 		existingPromise.Description = *updateData.Description
@@ -149,21 +294,40 @@ func UpdatePromise(userID uuid.UUID, promiseID string, updateData dto.UpdateProm
 	}
 	if updateData.IsPrivate != nil {
 		if existingPromise.ParentID != nil {
-			return errors.New("нельзя менять приватность у обновления прогресса")
+			return nil, nil, errors.New("нельзя менять приватность у обновления прогресса")
+		}
+		if existingPromise.IsPrivate && !*updateData.IsPrivate {
+			if err := ensureCanPublish(existingPromise.UserID); err != nil {
+				return nil, nil, err
+			}
 		}
 		existingPromise.IsPrivate = *updateData.IsPrivate
 	}
 	// Сохраняем обновления
-	return repositories.UpdatePromise(existingPromise)
+	if err := repositories.UpdatePromise(existingPromise); err != nil {
+		return nil, nil, err
+	}
+	return existingPromise, AuditDiff(&before, existingPromise), nil
 }
 
-// DeletePromise – удаление обещания (только для админа/модератора)
-func DeletePromise(promiseID string) error {
+// DeletePromise – удаление обещания (promise.delete.any или promise.delete.own); возвращает удалённое обещание
+func DeletePromise(actor authz.Actor, promiseID string) (*models.Promise, error) {
 	// Преобразуем в UUID
 	promiseUUID, err := uuid.Parse(promiseID)
 	if err != nil {
-		return errors.New("неверный формат ID обещания")
+		return nil, errors.New("неверный формат ID обещания")
 	}
 
-	return repositories.DeletePromise(promiseUUID)
+	promise, err := repositories.GetPromiseByID(promiseUUID)
+	if err != nil {
+		return nil, ErrPromiseNotFound
+	}
+	if !authz.Can(actor, authz.PromiseDelete, promise) {
+		return nil, ErrNotAllowedToDelete
+	}
+
+	if err := repositories.DeletePromise(promiseUUID); err != nil {
+		return nil, err
+	}
+	return promise, nil
 }
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/config"
+	"github.com/raxaris/ipromise-backend/internal/authz"
+	"github.com/raxaris/ipromise-backend/internal/dto"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"github.com/raxaris/ipromise-backend/internal/repositories"
+)
+
+// Ошибки
+var (
+	ErrCannotReportSelf    = errors.New("нельзя пожаловаться на себя или своё обещание")
+	ErrNoOpenReports       = errors.New("открытых жалоб на этот объект нет")
+	ErrInvalidResolution   = errors.New("такое решение неприменимо к этому объекту")
+	ErrNotAllowedToResolve = errors.New("у вас нет прав на это решение")
+)
+
+const maxReportsPage = 200
+
+// ReportPromise – жалоба на публичное обещание. Возвращает false, если жалоба уже отправлена ранее.
+func ReportPromise(reporterID, promiseID uuid.UUID, req dto.CreateReportRequest) (bool, error) {
+	promise, err := repositories.GetPromiseByID(promiseID)
+	if err != nil || promise.IsPrivate {
+		return false, ErrPromiseNotFound
+	}
+	if promise.UserID == reporterID {
+		return false, ErrCannotReportSelf
+	}
+	return createReport(reporterID, models.ReportTargetPromise, promiseID, req)
+}
+
+// ReportUser – жалоба на профиль пользователя. Возвращает false, если жалоба уже отправлена ранее.
+func ReportUser(reporterID uuid.UUID, username string, req dto.CreateReportRequest) (bool, error) {
+	user, err := GetUserByUsername(username)
+	if err != nil {
+		return false, err
+	}
+	if user.ID == reporterID {
+		return false, ErrCannotReportSelf
+	}
+	return createReport(reporterID, models.ReportTargetUser, user.ID, req)
+}
+
+func createReport(reporterID uuid.UUID, targetType string, targetID uuid.UUID, req dto.CreateReportRequest) (bool, error) {
+	return repositories.CreateReport(&models.Report{
+		ID:         uuid.New(),
+		ReporterID: reporterID,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Reason:     req.Reason,
+		Comment:    strings.TrimSpace(req.Comment),
+		Status:     models.ReportStatusOpen,
+	})
+}
+
+// GetReportQueue – очередь модерации: объекты с открытыми жалобами
+func GetReportQueue(targetType string, limit int) ([]dto.ReportQueueItem, error) {
+	if limit <= 0 || limit > maxReportsPage {
+		limit = maxReportsPage
+	}
+
+	rows, err := repositories.GetReportQueue(targetType, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	queue := make([]dto.ReportQueueItem, 0, len(rows))
+	for _, row := range rows {
+		queue = append(queue, dto.ReportQueueItem{
+			TargetType:      row.TargetType,
+			TargetID:        row.TargetID,
+			ReportCount:     row.ReportCount,
+			Reasons:         strings.Split(row.Reasons, ","),
+			FirstReportedAt: row.FirstReportedAt,
+			LastReportedAt:  row.LastReportedAt,
+		})
+	}
+	return queue, nil
+}
+
+// GetOpenReports – открытые жалобы на объект (с комментариями авторов)
+func GetOpenReports(targetType string, targetID uuid.UUID) ([]models.Report, error) {
+	return repositories.GetOpenReports(targetType, targetID)
+}
+
+// ResolveReports – решение модератора по объекту: закрывает все открытые жалобы и применяет его.
+// hidden – скрыть обещание; user_suspended – приостановить пользователя или автора обещания.
+func ResolveReports(actor authz.Actor, req dto.ResolveReportsRequest) (*models.ReportDecision, error) {
+	open, err := repositories.GetOpenReports(req.TargetType, req.TargetID)
+	if err != nil {
+		return nil, err
+	}
+	if len(open) == 0 {
+		return nil, ErrNoOpenReports
+	}
+
+	note := strings.TrimSpace(req.Note)
+	reason := "жалобы пользователей: " + open[0].Reason
+	if note != "" {
+		reason = note
+	}
+
+	// Права проверяются сразу, а решение применяется только после того, как открытые жалобы закрыты им:
+	// второй модератор, решающий параллельно, не скроет и не приостановит повторно без записи в истории
+	var apply func() error
+	switch req.Resolution {
+	case models.ReportResolutionDismissed:
+		// Нарушения нет – только закрываем жалобы
+
+	case models.ReportResolutionHidden:
+		if req.TargetType != models.ReportTargetPromise {
+			return nil, ErrInvalidResolution
+		}
+		if !authz.Can(actor, authz.PromiseHide, nil) {
+			return nil, ErrNotAllowedToResolve
+		}
+		apply = func() error {
+			if err := HidePromise(actor.ID, req.TargetID, reason); err != nil && !errors.Is(err, ErrPromiseAlreadyHidden) {
+				return err
+			}
+			return nil
+		}
+
+	case models.ReportResolutionUserSuspended:
+		if !authz.Can(actor, authz.UserSuspend, nil) {
+			return nil, ErrNotAllowedToResolve
+		}
+		userID := req.TargetID
+		if req.TargetType == models.ReportTargetPromise {
+			promise, err := repositories.GetPromiseByID(req.TargetID)
+			if err != nil {
+				return nil, ErrPromiseNotFound
+			}
+			userID = promise.UserID
+		}
+		apply = func() error {
+			return SuspendUser(actor.ID, userID, reason, time.Now().Add(config.ReportSuspensionDuration))
+		}
+
+	default:
+		return nil, ErrInvalidResolution
+	}
+
+	decision := models.ReportDecision{
+		ID:          uuid.New(),
+		TargetType:  req.TargetType,
+		TargetID:    req.TargetID,
+		ModeratorID: actor.ID,
+		Resolution:  req.Resolution,
+		Note:        note,
+	}
+	resolved, err := repositories.CreateReportDecision(&decision, apply)
+	if err != nil {
+		return nil, err
+	}
+	if !resolved {
+		return nil, ErrNoOpenReports
+	}
+	return &decision, nil
+}
+
+// GetReportDecisions – история решений модераторов
+func GetReportDecisions(targetType string, targetID *uuid.UUID, limit int) ([]models.ReportDecision, error) {
+	if limit <= 0 || limit > maxReportsPage {
+		limit = maxReportsPage
+	}
+	return repositories.GetReportDecisions(targetType, targetID, limit)
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/config"
+	"github.com/raxaris/ipromise-backend/internal/dto"
+	"github.com/raxaris/ipromise-backend/internal/repositories"
+)
+
+// Ошибки
+var (
+	ErrSessionNotFound = errors.New("сессия не найдена")
+)
+
+// GetUserSessions – список активных сессий пользователя
+func GetUserSessions(userID uuid.UUID) ([]dto.SessionResponse, error) {
+	tokens, err := repositories.GetActiveRefreshTokensByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]dto.SessionResponse, 0, len(tokens))
+	for _, token := range tokens {
+		sessions = append(sessions, dto.SessionResponse{
+			ID:         token.FamilyID,
+			CreatedAt:  token.SessionStartedAt,
+			LastUsedAt: token.LastUsedAt,
+			UserAgent:  token.UserAgent,
+			IP:         token.IP,
+		})
+	}
+	return sessions, nil
+}
+
+// RevokeSession – завершает одну сессию пользователя
+func RevokeSession(userID, sessionID uuid.UUID) error {
+	affected, err := repositories.RevokeRefreshTokenFamily(userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeAllSessions – завершает все сессии пользователя
+func RevokeAllSessions(userID uuid.UUID) error {
+	return repositories.RevokeAllRefreshTokens(userID)
+}
+
+// Logout – завершает сессию, к которой относится Refresh-токен
+func Logout(tokenString string) error {
+	refreshToken, err := ValidateRefreshTokenFromDB(config.DB, tokenString)
+	if err != nil {
+		return ErrInvalidRefreshToken
+	}
+	return RevokeTokenFamily(config.DB, refreshToken.FamilyID)
+}
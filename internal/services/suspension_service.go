@@ -0,0 +1,106 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/internal/authz"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"github.com/raxaris/ipromise-backend/internal/repositories"
+)
+
+// Ошибки
+var (
+	ErrAccountSuspended    = errors.New("аккаунт временно приостановлен")
+	ErrAccountBanned       = errors.New("аккаунт заблокирован")
+	ErrCannotSuspendStaff  = errors.New("нельзя приостановить аккаунт модератора или администратора")
+	ErrNotAllowedToSuspend = errors.New("у вас нет прав приостанавливать аккаунты")
+	ErrNotAllowedToBan     = errors.New("у вас нет прав блокировать аккаунты")
+	ErrInvalidSuspension   = errors.New("срок приостановки должен быть в будущем")
+	ErrUserNotRestricted   = errors.New("аккаунт не ограничен")
+	ErrUserAlreadyBanned   = errors.New("аккаунт уже заблокирован бессрочно")
+)
+
+// AccountRestrictedError – аккаунт приостановлен (Until задан) или заблокирован бессрочно
+type AccountRestrictedError struct {
+	Reason  error
+	Until   *time.Time
+	Comment string // Причина, указанная модератором
+}
+
+func (e *AccountRestrictedError) Error() string { return e.Reason.Error() }
+func (e *AccountRestrictedError) Unwrap() error { return e.Reason }
+
+// CheckAccountActive – ошибка AccountRestrictedError, если на аккаунт сейчас действует приостановка или бан
+func CheckAccountActive(user *models.User) error {
+	if !user.IsRestricted(time.Now()) {
+		return nil
+	}
+	if user.IsBanned() {
+		return &AccountRestrictedError{Reason: ErrAccountBanned, Comment: user.SuspendedReason}
+	}
+	return &AccountRestrictedError{Reason: ErrAccountSuspended, Until: user.SuspendedUntil, Comment: user.SuspendedReason}
+}
+
+// SuspendUser – приостанавливает аккаунт до until: вход запрещается, выданные токены и сессии отзываются,
+// публичные обещания пропадают из общих списков. Бессрочный бан приостановкой не заменяется.
+func SuspendUser(actorID, userID uuid.UUID, reason string, until time.Time) error {
+	if !until.After(time.Now()) {
+		return ErrInvalidSuspension
+	}
+
+	user, err := GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.IsBanned() {
+		return ErrUserAlreadyBanned
+	}
+	return restrictUser(actorID, user, reason, &until)
+}
+
+// BanUser – бессрочно блокирует аккаунт
+func BanUser(actorID, userID uuid.UUID, reason string) error {
+	user, err := GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	return restrictUser(actorID, user, reason, nil)
+}
+
+// LiftRestriction – снимает приостановку или бан. Снять бан может только тот, кто вправе банить.
+func LiftRestriction(actor authz.Actor, userID uuid.UUID) error {
+	user, err := GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if !user.IsRestricted(time.Now()) {
+		return ErrUserNotRestricted
+	}
+	if user.IsBanned() && !authz.Can(actor, authz.UserBan, nil) {
+		return ErrNotAllowedToBan
+	}
+	if err := repositories.LiftUserRestriction(userID); err != nil {
+		return err
+	}
+	forgetTokenVersion(userID)
+	return nil
+}
+
+// restrictUser – записывает ограничение и завершает все сессии пользователя
+func restrictUser(actorID uuid.UUID, user *models.User, reason string, until *time.Time) error {
+	// Сотрудники, которые сами могут приостанавливать аккаунты, решаются вне модерации
+	if authz.Can(authz.Actor{ID: user.ID, Role: user.Role}, authz.UserSuspend, nil) {
+		return ErrCannotSuspendStaff
+	}
+
+	if err := repositories.SuspendUser(user.ID, actorID, strings.TrimSpace(reason), until); err != nil {
+		return err
+	}
+	if err := BumpTokenVersion(user.ID); err != nil {
+		return err
+	}
+	return RevokeAllSessions(user.ID)
+}
@@ -1,55 +1,121 @@
 package services
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
 	"github.com/raxaris/ipromise-backend/internal/models"
 	"gorm.io/gorm"
-	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/raxaris/ipromise-backend/config"
 )
 
+// Ошибки
+var (
+	ErrInvalidRefreshToken = errors.New("недействительный или истёкший refresh-токен")
+	ErrRefreshTokenReused  = errors.New("refresh-токен уже был использован, сессия отозвана")
+)
+
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 7 * 24 * time.Hour
+)
+
+// AccessClaims – claims Access-токена; ID пользователя передаётся в `sub`
+type AccessClaims struct {
+	Role    string    `json:"role"`
+	Version int       `json:"ver"`           // TokenVersion пользователя на момент выдачи
+	AMR     []string  `json:"amr,omitempty"` // Способы входа: pwd, otp, ...
+	Act     *ActClaim `json:"act,omitempty"` // Есть только у токена имперсонации: кто действует от имени пользователя
+	jwt.RegisteredClaims
+}
+
+// ActClaim – действующее лицо (RFC 8693, claim `act`)
+type ActClaim struct {
+	Subject string `json:"sub"`
+	Version int    `json:"ver"` // TokenVersion администратора на момент выдачи
+}
+
+// UserID – ID пользователя из `sub`
+func (c *AccessClaims) UserID() (uuid.UUID, error) {
+	return uuid.Parse(c.Subject)
+}
+
 // GenerateAccessToken – создает Access-токен
-func GenerateAccessToken(userID, role string) (string, error) {
-	expirationTime := time.Now().Add(15 * time.Minute)
+func GenerateAccessToken(user *models.User, amr []string) (string, error) {
+	now := time.Now()
 
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"role":    role,
-		"exp":     expirationTime.Unix(),
+	claims := AccessClaims{
+		Role:    user.Role,
+		Version: user.TokenVersion,
+		AMR:     amr,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    config.JWTIssuer,
+			Subject:   user.ID.String(),
+			Audience:  jwt.ClaimStrings{config.JWTAudience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(accessTokenTTL)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        uuid.NewString(),
+		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(config.JWTSecret))
+	return signToken(claims)
 }
 
-// GenerateRefreshToken – создает Refresh-токен
-func GenerateRefreshToken(userID, role string) (string, error) {
-	expirationTime := time.Now().Add(7 * 24 * time.Hour)
+// GenerateRefreshToken – создает непрозрачный Refresh-токен (случайные 32 байта)
+func GenerateRefreshToken() (string, error) {
+	return generateOpaqueToken()
+}
 
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"role":    role,
-		"exp":     expirationTime.Unix(),
-	}
+// HashRefreshToken – ключевой хеш Refresh-токена; в БД хранится только он
+func HashRefreshToken(tokenString string) string {
+	return hashOpaqueToken(tokenString)
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(config.JWTSecret))
+// generateOpaqueToken – случайный токен для ссылок и сессий, 32 байта в base64url
+func generateOpaqueToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
 }
 
-// ValidateAccessToken – проверяет Access-токен
-func ValidateAccessToken(tokenString string) (jwt.MapClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, jwt.MapClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(config.JWTSecret), nil
-	})
+// hashOpaqueToken – HMAC-SHA256 непрозрачного токена для хранения в БД
+func hashOpaqueToken(tokenString string) string {
+	mac := hmac.New(sha256.New, []byte(config.RefreshTokenHashKey))
+	mac.Write([]byte(tokenString))
+	return hex.EncodeToString(mac.Sum(nil))
+}
 
+// ValidateAccessToken – проверяет Access-токен: подпись, алгоритм ключа, iss, aud, exp, nbf и iat
+func ValidateAccessToken(tokenString string) (*AccessClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &AccessClaims{}, lookupVerificationKey,
+		jwt.WithValidMethods(verificationMethods()),
+		jwt.WithIssuer(config.JWTIssuer),
+		jwt.WithAudience(config.JWTAudience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
 	if err != nil {
 		return nil, err
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
+	claims, ok := token.Claims.(*AccessClaims)
 	if !ok || !token.Valid {
-		return nil, err
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	if claims.Subject == "" || claims.ID == "" {
+		return nil, jwt.ErrTokenInvalidClaims
 	}
 
 	return claims, nil
@@ -58,7 +124,7 @@ func ValidateAccessToken(tokenString string) (jwt.MapClaims, error) {
 // ValidateRefreshTokenFromDB – проверяет `refresh_token` в БД
 func ValidateRefreshTokenFromDB(db *gorm.DB, tokenString string) (*models.RefreshToken, error) {
 	var refreshToken models.RefreshToken
-	if err := db.Where("token = ?", tokenString).First(&refreshToken).Error; err != nil {
+	if err := db.Where("token_hash = ? AND used_at IS NULL AND revoked_at IS NULL", HashRefreshToken(tokenString)).First(&refreshToken).Error; err != nil {
 		return nil, err
 	}
 
@@ -70,3 +136,141 @@ func ValidateRefreshTokenFromDB(db *gorm.DB, tokenString string) (*models.Refres
 
 	return &refreshToken, nil
 }
+
+// Способы входа для claim `amr`
+const (
+	AuthMethodPassword = "pwd"
+	AuthMethodOTP      = "otp"
+)
+
+// SessionInfo – данные клиента, сохраняемые вместе с Refresh-токеном
+type SessionInfo struct {
+	UserAgent   string
+	IP          string
+	AuthMethods []string // Для новой сессии; при ротации берутся из родительского токена
+}
+
+// IssueRefreshToken – создаёт Refresh-токен и сохраняет его в БД.
+// Если parent == nil, открывается новая сессия, иначе токен продолжает семейство parent.
+func IssueRefreshToken(db *gorm.DB, user *models.User, parent *models.RefreshToken, info SessionInfo) (*models.RefreshToken, string, error) {
+	tokenString, err := GenerateRefreshToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	refreshToken := models.RefreshToken{
+		ID:               uuid.New(),
+		UserID:           user.ID,
+		FamilyID:         uuid.New(),
+		TokenHash:        HashRefreshToken(tokenString),
+		ExpiresAt:        now.Add(refreshTokenTTL),
+		SessionStartedAt: now,
+		LastUsedAt:       now,
+		UserAgent:        info.UserAgent,
+		IP:               info.IP,
+		AuthMethods:      strings.Join(info.AuthMethods, ","),
+	}
+
+	if parent != nil {
+		refreshToken.FamilyID = parent.FamilyID
+		refreshToken.SessionStartedAt = parent.SessionStartedAt
+		refreshToken.AuthMethods = parent.AuthMethods
+	}
+
+	if err := db.Create(&refreshToken).Error; err != nil {
+		return nil, "", err
+	}
+
+	return &refreshToken, tokenString, nil
+}
+
+// IssueTokenPair – выдаёт Access-токен и открывает новую сессию с Refresh-токеном
+func IssueTokenPair(user *models.User, info SessionInfo) (string, string, error) {
+	accessToken, err := GenerateAccessToken(user, info.AuthMethods)
+	if err != nil {
+		return "", "", err
+	}
+
+	_, refreshToken, err := IssueRefreshToken(config.DB, user, nil, info)
+	if err != nil {
+		return "", "", err
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+// RotateRefreshToken – погашает предъявленный Refresh-токен и выдаёт новый в том же семействе.
+// Повторное предъявление уже погашенного токена отзывает всё семейство.
+func RotateRefreshToken(db *gorm.DB, tokenString string, info SessionInfo) (*models.RefreshToken, string, error) {
+	var current models.RefreshToken
+	if err := db.Where("token_hash = ?", HashRefreshToken(tokenString)).First(&current).Error; err != nil {
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	if current.RevokedAt != nil {
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	// Токен уже обменивали – вероятна кража, отзываем всю цепочку
+	if current.UsedAt != nil {
+		if err := RevokeTokenFamily(db, current.FamilyID); err != nil {
+			return nil, "", err
+		}
+		return nil, "", ErrRefreshTokenReused
+	}
+
+	if time.Now().After(current.ExpiresAt) {
+		db.Delete(&current) // Удаляем истёкший токен
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	user, err := GetUserByID(current.UserID)
+	if err != nil {
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	var next *models.RefreshToken
+	var nextString string
+	reused := false
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		next, nextString, err = IssueRefreshToken(tx, user, &current, info)
+		if err != nil {
+			return err
+		}
+
+		// Условие на used_at защищает от гонки двух одновременных обменов
+		res := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", current.ID).
+			Updates(map[string]interface{}{"used_at": time.Now(), "replaced_by_id": next.ID})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			reused = true
+			return ErrRefreshTokenReused
+		}
+		return nil
+	})
+
+	if reused {
+		if err := RevokeTokenFamily(db, current.FamilyID); err != nil {
+			return nil, "", err
+		}
+		return nil, "", ErrRefreshTokenReused
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	return next, nextString, nil
+}
+
+// RevokeTokenFamily – отзывает все ещё не отозванные токены семейства
+func RevokeTokenFamily(db *gorm.DB, familyID uuid.UUID) error {
+	return db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/internal/models"
+	"github.com/raxaris/ipromise-backend/internal/repositories"
+	"gorm.io/gorm"
+)
+
+// Сколько версия токенов живёт в памяти до перечитывания из Postgres.
+// Ограничивает задержку отзыва, если версию увеличил другой экземпляр сервиса.
+const tokenVersionTTL = 30 * time.Second
+
+type tokenVersionEntry struct {
+	version   int
+	missing   bool         // Пользователь удалён или не найден
+	user      *models.User // Роль и ограничения аккаунта (приостановка, бан) на момент чтения
+	fetchedAt time.Time
+}
+
+var tokenVersions = struct {
+	sync.RWMutex
+	entries map[uuid.UUID]tokenVersionEntry
+	sweptAt time.Time // Последняя очистка устаревших записей
+}{entries: map[uuid.UUID]tokenVersionEntry{}}
+
+// IsAccessTokenCurrent – проверяет, что токен выдан с актуальной версией пользователя
+func IsAccessTokenCurrent(claims *AccessClaims) (bool, error) {
+	userID, err := claims.UserID()
+	if err != nil {
+		return false, err
+	}
+
+	entry, err := cachedTokenVersion(userID)
+	if err != nil {
+		return false, err
+	}
+	return !entry.missing && entry.version == claims.Version, nil
+}
+
+// CheckUserActive – CheckAccountActive по кешу версий токенов (проверка на каждом запросе без обращения к БД)
+func CheckUserActive(userID uuid.UUID) error {
+	entry, err := cachedTokenVersion(userID)
+	if err != nil {
+		return err
+	}
+	if entry.missing {
+		return nil
+	}
+	return CheckAccountActive(entry.user)
+}
+
+// BumpTokenVersion – отзывает все выданные Access-токены пользователя.
+// Вызывается при смене роли, пароля, блокировке и удалении аккаунта.
+func BumpTokenVersion(userID uuid.UUID) error {
+	if _, err := repositories.IncrementTokenVersion(userID); err != nil {
+		return err
+	}
+	// Следующий запрос перечитает версию вместе с ограничениями аккаунта
+	forgetTokenVersion(userID)
+	return nil
+}
+
+// cachedTokenVersion – запись из кеша или из БД, если она устарела
+func cachedTokenVersion(userID uuid.UUID) (tokenVersionEntry, error) {
+	tokenVersions.RLock()
+	entry, ok := tokenVersions.entries[userID]
+	tokenVersions.RUnlock()
+
+	if ok && time.Since(entry.fetchedAt) <= tokenVersionTTL {
+		return entry, nil
+	}
+	return loadTokenVersion(userID)
+}
+
+// loadTokenVersion – читает версию и ограничения аккаунта из БД и кладёт их в кеш
+func loadTokenVersion(userID uuid.UUID) (tokenVersionEntry, error) {
+	entry := tokenVersionEntry{fetchedAt: time.Now()}
+
+	user, err := repositories.GetUserAccessState(userID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return entry, err
+		}
+		entry.missing = true
+	}
+	entry.version = user.TokenVersion
+	entry.user = user
+
+	tokenVersions.Lock()
+	tokenVersions.entries[userID] = entry
+	// Устаревшие записи всё равно перечитываются – удаляем их не чаще раза за TTL, чтобы кеш не рос
+	// с каждым пользователем, который когда-либо входил
+	if entry.fetchedAt.Sub(tokenVersions.sweptAt) > tokenVersionTTL {
+		for id, cached := range tokenVersions.entries {
+			if entry.fetchedAt.Sub(cached.fetchedAt) > tokenVersionTTL {
+				delete(tokenVersions.entries, id)
+			}
+		}
+		tokenVersions.sweptAt = entry.fetchedAt
+	}
+	tokenVersions.Unlock()
+	return entry, nil
+}
+
+// forgetTokenVersion – сбрасывает запись кеша после изменения версии или ограничений
+func forgetTokenVersion(userID uuid.UUID) {
+	tokenVersions.Lock()
+	delete(tokenVersions.entries, userID)
+	tokenVersions.Unlock()
+}
@@ -5,8 +5,10 @@ import (
 	"strings"
 
 	"github.com/google/uuid"
+	"github.com/raxaris/ipromise-backend/internal/authz"
 	"github.com/raxaris/ipromise-backend/internal/dto"
 	"github.com/raxaris/ipromise-backend/internal/models"
+	"github.com/raxaris/ipromise-backend/internal/pagination"
 	"github.com/raxaris/ipromise-backend/internal/repositories"
 )
 
@@ -16,6 +18,7 @@ var (
 	ErrUsernameTaken    = errors.New("это имя пользователя уже занято")
 	ErrEmailTaken       = errors.New("этот email уже используется")
 	ErrNotAllowedToEdit = errors.New("у вас нет прав для редактирования этого пользователя")
+	ErrUnknownRole      = errors.New("такой роли нет")
 )
 
 // CreateUser – создание пользователя
@@ -54,9 +57,15 @@ func CreateUser(username, email, password string) (*models.User, error) {
 	return user, err
 }
 
-// GetAllUsers – получение всех пользователей
-func GetAllUsers() ([]models.User, error) {
-	return repositories.GetAllUsers()
+// ListUsers – страница пользователей с курсором следующей страницы
+func ListUsers(role string, page pagination.Page) (pagination.Result[models.User], error) {
+	users, err := repositories.ListUsers(role, page)
+	if err != nil {
+		return pagination.Result[models.User]{}, err
+	}
+	return pagination.Finish(users, page, func(u models.User) pagination.Cursor {
+		return pagination.Cursor{Value: u.CreatedAt, ID: u.ID}
+	}), nil
 }
 
 // GetUserByID – получение пользователя по ID
@@ -77,40 +86,67 @@ func GetUserByUsername(username string) (*models.User, error) {
 	return user, nil
 }
 
-// UpdateUser – обновление пользователя
-func UpdateUser(requesterID uuid.UUID, userID uuid.UUID, req *dto.UpdateUserRequest, isAdmin bool) error {
+// UpdateUser – обновление пользователя (с учетом разрешений actor); возвращает изменённые поля для журнала аудита
+func UpdateUser(actor authz.Actor, userID uuid.UUID, req *dto.UpdateUserRequest) (models.AuditChanges, error) {
 	// Получаем существующего пользователя
 	existingUser, err := repositories.GetUserByID(userID)
 	if err != nil {
-		return ErrUserNotFound
+		return nil, ErrUserNotFound
 	}
 
 	// Проверяем, имеет ли пользователь право редактировать
-	if requesterID != userID && !isAdmin {
-		return ErrNotAllowedToEdit
+	if !authz.Can(actor, authz.UserUpdate, existingUser) {
+		return nil, ErrNotAllowedToEdit
 	}
 
+	before := *existingUser
+
 	// Проверяем уникальность username, если его меняют
 	if req.Username != nil {
 		newUsername := strings.TrimSpace(*req.Username)
 		if newUsername != existingUser.Username {
 			if repositories.IsUsernameExists(newUsername) {
-				return ErrUsernameTaken
+				return nil, ErrUsernameTaken
 			}
 			existingUser.Username = newUsername
 		}
 	}
 
-	// Админ может менять роль
-	if isAdmin && req.Role != nil {
+	// Роль меняется только при разрешении user.role.set и только на описанную в политике
+	roleChanged := false
+	if req.Role != nil && *req.Role != existingUser.Role && authz.Can(actor, authz.UserSetRole, nil) {
+		if !authz.IsKnownRole(*req.Role) {
+			return nil, ErrUnknownRole
+		}
 		existingUser.Role = *req.Role
+		roleChanged = true
 	}
 
 	// Обновляем пользователя в БД
-	return repositories.UpdateUser(existingUser)
+	if err := repositories.UpdateUser(existingUser); err != nil {
+		return nil, err
+	}
+
+	// Старые токены несут прежнюю роль – отзываем их вместе с персональными
+	if roleChanged {
+		if err := BumpTokenVersion(userID); err != nil {
+			return nil, err
+		}
+		if err := repositories.RevokeAllPersonalTokens(userID); err != nil {
+			return nil, err
+		}
+	}
+	return AuditDiff(&before, existingUser), nil
 }
 
 // DeleteUser – удаление пользователя
 func DeleteUser(userID uuid.UUID) error {
+	// Отзываем токены до удаления: у мягко удалённой записи версию уже не поднять
+	if err := BumpTokenVersion(userID); err != nil {
+		return err
+	}
+	if err := RevokeAllSessions(userID); err != nil {
+		return err
+	}
 	return repositories.DeleteUser(userID)
 }